@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeyEntry describes an API key, the key itself is only stored as an hex encoded SHA-256 hash (see HashAPIKey)
+type APIKeyEntry struct {
+	Hash  string `json:"hash"`
+	Owner string `json:"owner"`
+	// Scope is a space separated list of scopes
+	Scope string `json:"scope,omitempty"`
+	// Expiry is the key expiration time, a zero value never expires
+	Expiry   time.Time         `json:"expires_at,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Scopes returns the key scopes
+func (e *APIKeyEntry) Scopes() []string {
+	return strings.Fields(e.Scope)
+}
+
+func (e *APIKeyEntry) ExpiresAt() time.Time {
+	return e.Expiry
+}
+
+// Subject returns the key owner
+func (e *APIKeyEntry) Subject() string {
+	return e.Owner
+}
+
+func (e *APIKeyEntry) Issuer() string {
+	return ""
+}
+
+// Claims returns the key metadata
+func (e *APIKeyEntry) Claims() map[string]interface{} {
+	claims := make(map[string]interface{}, len(e.Metadata))
+	for name, value := range e.Metadata {
+		claims[name] = value
+	}
+	return claims
+}
+
+func (e *APIKeyEntry) AuthMethod() string {
+	return AuthMethodAPIKey
+}
+
+// HashAPIKey returns the hash to store in the APIKeyEntry
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyStore finds the entry of an API key
+type APIKeyStore interface {
+	Lookup(key string) (*APIKeyEntry, bool)
+}
+
+// APIKeys is an in-memory APIKeyStore
+type APIKeys []APIKeyEntry
+
+// Lookup compares the key hash with every entries in constant time
+func (a APIKeys) Lookup(key string) (*APIKeyEntry, bool) {
+	hash := []byte(HashAPIKey(key))
+	var found *APIKeyEntry
+	for i := range a {
+		if subtle.ConstantTimeCompare(hash, []byte(strings.ToLower(a[i].Hash))) == 1 {
+			found = &a[i]
+		}
+	}
+	if found == nil {
+		return nil, false
+	}
+	entry := *found
+	return &entry, true
+}
+
+// APIKeyFile is an APIKeyStore backed by a JSON file containing an array of APIKeyEntry
+// the file is reloaded as soon as it changes
+type APIKeyFile struct {
+	file  *watchedFile
+	mutex sync.RWMutex
+	keys  APIKeys
+}
+
+func (a *APIKeyFile) Lookup(key string) (*APIKeyEntry, bool) {
+	_ = a.file.refresh()
+	a.mutex.RLock()
+	keys := a.keys
+	a.mutex.RUnlock()
+	return keys.Lookup(key)
+}
+
+func (a *APIKeyFile) load(reader io.Reader) error {
+	keys, err := ParseAPIKeys(reader)
+	if err != nil {
+		return err
+	}
+	a.mutex.Lock()
+	a.keys = keys
+	a.mutex.Unlock()
+	return nil
+}
+
+// NewAPIKeyFile loads the API keys file, it returns an error if the file cannot be loaded
+func NewAPIKeyFile(path string) (*APIKeyFile, error) {
+	a := &APIKeyFile{}
+	file, err := newWatchedFile(path, a.load)
+	if err != nil {
+		return nil, err
+	}
+	a.file = file
+	return a, nil
+}
+
+// ParseAPIKeys reads a JSON array of APIKeyEntry
+func ParseAPIKeys(reader io.Reader) (APIKeys, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	keys := APIKeys{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("malformed api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// APIKeyAuthenticator authenticates APIKey credentials against an APIKeyStore
+// it returns the *APIKeyEntry as authenticated credential
+type APIKeyAuthenticator struct {
+	store APIKeyStore
+}
+
+func (a *APIKeyAuthenticator) Authenticate(_ context.Context, credential Credential) (Credential, error) {
+	var key APIKey
+	switch c := credential.(type) {
+	case APIKey:
+		key = c
+	case string:
+		// a raw key (ex: from a dedicated header) or an "ApiKey <key>" authorization
+		if !strings.Contains(c, " ") {
+			key = APIKey(c)
+			break
+		}
+		parsed, err := ParseCredential(c)
+		if err != nil {
+			return nil, a.error(err)
+		}
+		key, _ = parsed.(APIKey)
+	}
+	if key == "" {
+		return nil, a.error(ErrMissingCredential)
+	}
+
+	entry, ok := a.store.Lookup(string(key))
+	if !ok {
+		return nil, a.error(ErrInvalidCredential)
+	}
+	if !entry.Expiry.IsZero() && time.Now().After(entry.Expiry) {
+		return nil, &Error{Err: ErrExpired, Scheme: APIKeyScheme, Description: "api key expired"}
+	}
+	return entry, nil
+}
+
+func (a *APIKeyAuthenticator) error(err error) error {
+	return &Error{Err: err, Scheme: APIKeyScheme}
+}
+
+func NewAPIKeyAuthenticator(store APIKeyStore) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{
+		store: store,
+	}
+}
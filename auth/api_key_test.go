@@ -0,0 +1,96 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashAPIKey(t *testing.T) {
+	assert.Equal(t, "7d4c144301af71a908e50a263437f2968e5955e980cdeb5f83310b7d85c9c60d", auth.HashAPIKey("my_key"))
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	authenticator := auth.NewAPIKeyAuthenticator(auth.APIKeys{
+		{Hash: auth.HashAPIKey("my_key"), Owner: "foo", Scope: "read write"},
+		{Hash: auth.HashAPIKey("my_expired_key"), Owner: "bar", Expiry: time.Now().Add(-time.Minute)},
+	})
+
+	tests := []struct {
+		credential    auth.Credential
+		expectedOwner string
+		expectedErr   string
+	}{
+		{credential: auth.APIKey("my_key"), expectedOwner: "foo"},
+		{credential: "my_key", expectedOwner: "foo"},
+		{credential: "ApiKey my_key", expectedOwner: "foo"},
+		{credential: nil, expectedErr: "missing credential"},
+		{credential: "", expectedErr: "missing credential"},
+		{credential: "Bearer my_key", expectedErr: "missing credential"},
+		{credential: "ApiKey ", expectedErr: "invalid credential"},
+		{credential: auth.APIKey("my_unknown_key"), expectedErr: "invalid credential"},
+		{credential: auth.APIKey("my_expired_key"), expectedErr: "expired credential"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			credential, err := authenticator.Authenticate(context.Background(), tt.credential)
+			if tt.expectedErr != "" {
+				assert.Nil(t, credential)
+				assert.EqualError(t, err, tt.expectedErr)
+				authErr := &auth.Error{}
+				assert.True(t, errors.As(err, &authErr))
+				assert.Equal(t, "ApiKey", authErr.Challenge())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedOwner, credential.(*auth.APIKeyEntry).Owner)
+		})
+	}
+}
+
+func TestAPIKeyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "api_keys")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "api_keys.json")
+
+	_, err = auth.NewAPIKeyFile(path)
+	assert.Error(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`[{"hash":"`+auth.HashAPIKey("my_key")+`","owner":"foo","scope":"read","expires_at":"2100-01-02T15:04:05Z","metadata":{"team":"my_team"}}]`), 0600))
+	store, err := auth.NewAPIKeyFile(path)
+	assert.NoError(t, err)
+
+	entry, ok := store.Lookup("my_key")
+	assert.True(t, ok)
+	assert.Equal(t, &auth.APIKeyEntry{
+		Hash:     auth.HashAPIKey("my_key"),
+		Owner:    "foo",
+		Scope:    "read",
+		Expiry:   time.Date(2100, 1, 2, 15, 4, 5, 0, time.UTC),
+		Metadata: map[string]string{"team": "my_team"},
+	}, entry)
+	_, ok = store.Lookup("my_other_key")
+	assert.False(t, ok)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`[{"hash":"`+auth.HashAPIKey("my_other_key")+`","owner":"bar"}]`), 0600))
+	_, ok = store.Lookup("my_key")
+	assert.False(t, ok)
+	entry, ok = store.Lookup("my_other_key")
+	assert.True(t, ok)
+	assert.Equal(t, "bar", entry.Owner)
+
+	// a malformed file keeps the previous keys
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`malformed`), 0600))
+	_, ok = store.Lookup("my_other_key")
+	assert.True(t, ok)
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+)
+
+const BasicScheme = "Basic"
+
+// PasswordVerifier checks a username/password pair
+type PasswordVerifier interface {
+	Verify(username string, password string) bool
+}
+
+// BasicAuthenticator authenticates BasicCredential or raw "Basic <base64(username:password)>" credentials
+// it returns the UserPrincipal as authenticated credential
+type BasicAuthenticator struct {
+	realm    string
+	verifier PasswordVerifier
+}
+
+func (b *BasicAuthenticator) Authenticate(_ context.Context, credential Credential) (Credential, error) {
+	if raw, ok := credential.(string); ok {
+		parsed, err := ParseCredential(raw)
+		if err != nil {
+			return nil, b.error(err)
+		}
+		credential = parsed
+	}
+	basic, ok := credential.(BasicCredential)
+	if !ok {
+		return nil, b.error(ErrMissingCredential)
+	}
+	if !b.verifier.Verify(basic.Username, basic.Password) {
+		return nil, b.error(ErrInvalidCredential)
+	}
+	return UserPrincipal{Username: basic.Username, Method: AuthMethodBasic}, nil
+}
+
+func (b *BasicAuthenticator) error(err error) error {
+	return &Error{Err: err, Scheme: BasicScheme, Realm: b.realm}
+}
+
+// NewBasicAuthenticator creates a BasicAuthenticator, the realm is sent back in the challenge when the authentication fails
+func NewBasicAuthenticator(realm string, verifier PasswordVerifier) *BasicAuthenticator {
+	return &BasicAuthenticator{
+		realm:    realm,
+		verifier: verifier,
+	}
+}
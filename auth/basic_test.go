@@ -0,0 +1,50 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasicAuthenticator(t *testing.T) {
+	authenticator := auth.NewBasicAuthenticator("my_realm", auth.Passwords{
+		// password: "my_password"
+		"foo": "{SHA}XrlCgQp168hQlyqJKF1XDUhMicQ=",
+	})
+
+	tests := []struct {
+		credential         auth.Credential
+		expectedCredential auth.Credential
+		expectedErr        error
+	}{
+		{credential: nil, expectedErr: auth.ErrMissingCredential},
+		{credential: "", expectedErr: auth.ErrMissingCredential},
+		{credential: "Bearer my_token", expectedErr: auth.ErrMissingCredential},
+		{credential: "Basic !!!", expectedErr: auth.ErrInvalidCredential},
+		{credential: auth.BearerToken("my_token"), expectedErr: auth.ErrMissingCredential},
+		{credential: "Basic Zm9vOndyb25n", expectedErr: auth.ErrInvalidCredential},
+		{credential: "Basic YmFyOm15X3Bhc3N3b3Jk", expectedErr: auth.ErrInvalidCredential},
+		{credential: "Basic Zm9vOm15X3Bhc3N3b3Jk", expectedCredential: auth.UserPrincipal{Username: "foo", Method: auth.AuthMethodBasic}},
+		{credential: "basic Zm9vOm15X3Bhc3N3b3Jk", expectedCredential: auth.UserPrincipal{Username: "foo", Method: auth.AuthMethodBasic}},
+		{credential: auth.BasicCredential{Username: "foo", Password: "my_password"}, expectedCredential: auth.UserPrincipal{Username: "foo", Method: auth.AuthMethodBasic}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			credential, err := authenticator.Authenticate(context.Background(), tt.credential)
+			assert.Equal(t, tt.expectedCredential, credential)
+			if tt.expectedErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tt.expectedErr))
+			authErr := &auth.Error{}
+			assert.True(t, errors.As(err, &authErr))
+			assert.Equal(t, `Basic realm="my_realm"`, authErr.Challenge())
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Expirable is implemented by the credentials that know their expiration time, a zero time never expires
+type Expirable interface {
+	ExpiresAt() time.Time
+}
+
+// SingleUseCredential is implemented by the credentials that must be verified on each use (ie: the Digest nonce counts)
+// they are never cached
+type SingleUseCredential interface {
+	SingleUse() bool
+}
+
+// OriginalCredential is implemented by the authenticated credentials that keep the credential sent by the client
+// (ie: the raw token of a parsed JWT), CredentialKey identifies them by this credential
+type OriginalCredential interface {
+	OriginalCredential() Credential
+}
+
+// CredentialKey returns a hash identifying the credential, ok is false when the credential cannot be identified
+// or must not be cached (see SingleUseCredential)
+func CredentialKey(credential Credential) (key string, ok bool) {
+	if original, isOriginal := credential.(OriginalCredential); isOriginal {
+		return CredentialKey(original.OriginalCredential())
+	}
+	if raw, isString := credential.(string); isString {
+		if parsed, err := ParseCredential(raw); err == nil {
+			if singleUse, ok := parsed.(SingleUseCredential); ok && singleUse.SingleUse() {
+				return "", false
+			}
+		}
+	}
+	if singleUse, ok := credential.(SingleUseCredential); ok && singleUse.SingleUse() {
+		return "", false
+	}
+
+	var raw []byte
+	switch c := credential.(type) {
+	case string:
+		raw = []byte(c)
+	case SchemeCredential:
+		raw = []byte(FormatCredential(c))
+	case *ClientCertificate:
+		if c == nil || len(c.Chain) == 0 {
+			return "", false
+		}
+		raw = c.Chain[0].Raw
+	default:
+		return "", false
+	}
+	if len(raw) == 0 {
+		return "", false
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// CachedAuthenticator memoizes the authentication results of an Authenticator
+type CachedAuthenticator struct {
+	authenticator Authenticator
+	ttl           time.Duration
+	maxEntries    int
+	config        *CacheConfig
+
+	group   singleflight.Group
+	mutex   sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key        string
+	credential Credential
+	err        error
+	expiresAt  time.Time
+}
+
+type cacheResult struct {
+	credential Credential
+	err        error
+}
+
+func (c *CachedAuthenticator) Authenticate(ctx context.Context, credential Credential) (Credential, error) {
+	key, ok := CredentialKey(credential)
+	if !ok {
+		return c.authenticator.Authenticate(ctx, credential)
+	}
+	// the same credential can be subject to other rules when it comes from another part of the request
+	if source, ok := CredentialSourceFromContext(ctx); ok {
+		key += "|" + source.Location + "|" + source.Name + "|" + source.Method
+	}
+	if entry, ok := c.get(key); ok {
+		return entry.credential, entry.err
+	}
+
+	// concurrent authentications of the same credential share the same call,
+	// it runs on a context detached from the callers so the cancellation of one caller does not fail the others
+	results := c.group.DoChan(key, func() (interface{}, error) {
+		creds, err := c.authenticator.Authenticate(detachedContext{ctx}, credential)
+		c.set(key, creds, err)
+		return cacheResult{credential: creds, err: err}, nil
+	})
+	select {
+	case result := <-results:
+		r := result.Val.(cacheResult)
+		return r.credential, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// detachedContext keeps the values of the parent context without its deadline and cancellation
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
+
+func (c *CachedAuthenticator) get(key string) (*cacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		c.lru.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return entry, true
+}
+
+func (c *CachedAuthenticator) set(key string, credential Credential, err error) {
+	ttl := c.ttl
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrUnavailable) {
+			return
+		}
+		ttl = c.config.FailureTTL
+	}
+	expiresAt := time.Now().Add(ttl)
+	if expirable, ok := credential.(Expirable); ok && err == nil {
+		if exp := expirable.ExpiresAt(); !exp.IsZero() && exp.Before(expiresAt) {
+			expiresAt = exp
+		}
+	}
+	if ttl <= 0 || !time.Now().Before(expiresAt) {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.lru.Remove(element)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, credential: credential, err: err, expiresAt: expiresAt})
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Cached decorates the authenticator with a LRU cache of maxEntries successful authentications kept for the ttl
+// or until the credential expires when it implements Expirable, a credential is cached per source (see CredentialSourceFromContext)
+func Cached(authenticator Authenticator, ttl time.Duration, maxEntries int, options ...CacheOption) *CachedAuthenticator {
+	return &CachedAuthenticator{
+		authenticator: authenticator,
+		ttl:           ttl,
+		maxEntries:    maxEntries,
+		config:        NewCacheConfig(options...),
+		lru:           list.New(),
+		entries:       map[string]*list.Element{},
+	}
+}
+
+type CacheConfig struct {
+	// FailureTTL is the time the failed authentications are cached, they are not cached when it is zero
+	FailureTTL time.Duration
+}
+
+func (c *CacheConfig) apply(options ...CacheOption) *CacheConfig {
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// NewCacheConfig returns a new cache configuration with all options applied
+func NewCacheConfig(options ...CacheOption) *CacheConfig {
+	config := &CacheConfig{}
+	return config.apply(options...)
+}
+
+// CacheOption defines a Cached authenticator configuration option
+type CacheOption func(*CacheConfig)
+
+// WithFailureTTL will configure FailureTTL option
+func WithFailureTTL(failureTTL time.Duration) CacheOption {
+	return func(config *CacheConfig) {
+		config.FailureTTL = failureTTL
+	}
+}
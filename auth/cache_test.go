@@ -0,0 +1,172 @@
+package auth_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func countingAuthenticator(calls *int32) auth.Authenticator {
+	return auth.AuthenticatorFunc(func(_ context.Context, credential auth.Credential) (auth.Credential, error) {
+		atomic.AddInt32(calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		switch credential {
+		case "my_credential":
+			return "authenticated", nil
+		case "my_expiring_credential":
+			return &auth.APIKeyEntry{Expiry: time.Now().Add(50 * time.Millisecond)}, nil
+		}
+		return nil, auth.ErrInvalidCredential
+	})
+}
+
+func TestCredentialKey(t *testing.T) {
+	key, ok := auth.CredentialKey("Bearer my_token")
+	assert.True(t, ok)
+	bearerKey, ok := auth.CredentialKey(auth.BearerToken("my_token"))
+	assert.True(t, ok)
+	assert.Equal(t, key, bearerKey)
+
+	_, ok = auth.CredentialKey("")
+	assert.False(t, ok)
+	_, ok = auth.CredentialKey(nil)
+	assert.False(t, ok)
+	_, ok = auth.CredentialKey(&auth.ClientCertificate{})
+	assert.False(t, ok)
+	_, ok = auth.CredentialKey(auth.DigestCredential{Username: "foo", Nonce: "my_nonce", Response: "my_response"})
+	assert.False(t, ok)
+	_, ok = auth.CredentialKey(`Digest username="foo", nonce="my_nonce", response="my_response"`)
+	assert.False(t, ok)
+}
+
+func TestCached_Digest(t *testing.T) {
+	cached := auth.Cached(auth.NewDigestAuthenticator("my_realm", auth.DigestPasswords{"foo": "my_password"}), time.Minute, 10)
+
+	_, err := cached.Authenticate(context.Background(), nil)
+	digest, _ := challengeOf(t, err, auth.DigestSHA256).Authorize("GET", "/my_path", "foo", "my_password", "my_cnonce", 1)
+	credential, err := cached.Authenticate(context.Background(), digest)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", credential.(auth.Principal).Subject())
+
+	// the replayed credential is not served from the cache
+	credential, err = cached.Authenticate(context.Background(), digest)
+	assert.Nil(t, credential)
+	assert.EqualError(t, err, "invalid credential: replayed nonce count; invalid credential: replayed nonce count")
+}
+
+func TestCached(t *testing.T) {
+	var calls int32
+	cached := auth.Cached(countingAuthenticator(&calls), time.Minute, 10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			credential, err := cached.Authenticate(context.Background(), "my_credential")
+			assert.NoError(t, err)
+			assert.Equal(t, "authenticated", credential)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+
+	credential, err := cached.Authenticate(context.Background(), "my_credential")
+	assert.NoError(t, err)
+	assert.Equal(t, "authenticated", credential)
+	assert.Equal(t, int32(1), calls)
+
+	// failures are not cached by default
+	for i := 0; i < 2; i++ {
+		credential, err = cached.Authenticate(context.Background(), "other")
+		assert.Nil(t, credential)
+		assert.Equal(t, auth.ErrInvalidCredential, err)
+	}
+	assert.Equal(t, int32(3), calls)
+
+	// not identifiable credentials are not cached
+	_, _ = cached.Authenticate(context.Background(), nil)
+	_, _ = cached.Authenticate(context.Background(), nil)
+	assert.Equal(t, int32(5), calls)
+}
+
+func TestCached_Failure(t *testing.T) {
+	var calls int32
+	cached := auth.Cached(countingAuthenticator(&calls), time.Minute, 10, auth.WithFailureTTL(time.Minute))
+
+	for i := 0; i < 2; i++ {
+		credential, err := cached.Authenticate(context.Background(), "other")
+		assert.Nil(t, credential)
+		assert.Equal(t, auth.ErrInvalidCredential, err)
+	}
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestCached_Expiry(t *testing.T) {
+	var calls int32
+	cached := auth.Cached(countingAuthenticator(&calls), time.Minute, 10)
+
+	_, _ = cached.Authenticate(context.Background(), "my_expiring_credential")
+	_, _ = cached.Authenticate(context.Background(), "my_expiring_credential")
+	assert.Equal(t, int32(1), calls)
+
+	// the credential expired before the cache ttl
+	time.Sleep(50 * time.Millisecond)
+	_, _ = cached.Authenticate(context.Background(), "my_expiring_credential")
+	assert.Equal(t, int32(2), calls)
+}
+
+func TestCached_MaxEntries(t *testing.T) {
+	var calls int32
+	cached := auth.Cached(countingAuthenticator(&calls), time.Minute, 1, auth.WithFailureTTL(time.Minute))
+
+	_, _ = cached.Authenticate(context.Background(), "my_credential")
+	_, _ = cached.Authenticate(context.Background(), "other")
+	assert.Equal(t, int32(2), calls)
+	// my_credential was evicted by other
+	_, _ = cached.Authenticate(context.Background(), "my_credential")
+	assert.Equal(t, int32(3), calls)
+	_, _ = cached.Authenticate(context.Background(), "my_credential")
+	assert.Equal(t, int32(3), calls)
+}
+
+func TestCached_Source(t *testing.T) {
+	var calls int32
+	cached := auth.Cached(countingAuthenticator(&calls), time.Minute, 10)
+
+	headerCtx := auth.CredentialSourceToContext(context.Background(), auth.CredentialSource{Location: auth.SourceHeader, Name: "Authorization", Method: "GET"})
+	queryCtx := auth.CredentialSourceToContext(context.Background(), auth.CredentialSource{Location: auth.SourceQuery, Name: "access_token", Method: "GET"})
+
+	_, _ = cached.Authenticate(headerCtx, "my_credential")
+	_, _ = cached.Authenticate(headerCtx, "my_credential")
+	assert.Equal(t, int32(1), calls)
+	// the credential sent in another part of the request is authenticated again
+	_, _ = cached.Authenticate(queryCtx, "my_credential")
+	assert.Equal(t, int32(2), calls)
+}
+
+func TestCached_CanceledCaller(t *testing.T) {
+	var calls int32
+	cached := auth.Cached(countingAuthenticator(&calls), time.Minute, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		credential, err := cached.Authenticate(context.Background(), "my_credential")
+		assert.NoError(t, err)
+		assert.Equal(t, "authenticated", credential)
+	}()
+	time.AfterFunc(time.Millisecond, cancel)
+	credential, err := cached.Authenticate(ctx, "my_credential")
+	assert.Nil(t, credential)
+	assert.Equal(t, context.Canceled, err)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+}
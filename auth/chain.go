@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Errors aggregates the errors of several authenticators
+// errors.Is and errors.As look into every error
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// FirstOf returns the credential of the first authenticator that succeeds
+// when they all fail, the Errors of every attempt are returned
+func FirstOf(authenticators ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, credential Credential) (Credential, error) {
+		errs := make(Errors, 0, len(authenticators))
+		for _, authenticator := range authenticators {
+			creds, err := authenticator.Authenticate(ctx, credential)
+			if err == nil {
+				return creds, nil
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) == 0 {
+			return nil, ErrMissingCredential
+		}
+		return nil, errs
+	})
+}
+
+// AllOf succeeds when all authenticators succeed, it returns the credential of the first one
+func AllOf(authenticators ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, credential Credential) (Credential, error) {
+		var result Credential
+		for i, authenticator := range authenticators {
+			creds, err := authenticator.Authenticate(ctx, credential)
+			if err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				result = creds
+			}
+		}
+		if result == nil {
+			return nil, ErrMissingCredential
+		}
+		return result, nil
+	})
+}
+
+// SchemeRouter dispatches the credential to the authenticator registered for its scheme (case insensitive)
+// ex: SchemeRouter{BearerScheme: jwtAuthenticator, APIKeyScheme: apiKeyAuthenticator, ClientCertificateScheme: mtlsAuthenticator}
+// when the credential is missing or its scheme has no authenticator, the errors (and challenges)
+// of every authenticator given a nil credential are aggregated
+type SchemeRouter map[string]Authenticator
+
+func (s SchemeRouter) Authenticate(ctx context.Context, credential Credential) (Credential, error) {
+	if scheme := CredentialScheme(credential); scheme != "" {
+		for name, authenticator := range s {
+			if strings.EqualFold(name, scheme) {
+				return authenticator.Authenticate(ctx, credential)
+			}
+		}
+	}
+
+	errs := make(Errors, 0, len(s))
+	for _, name := range sortedKeys(s) {
+		if _, err := s[name].Authenticate(ctx, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if credential != nil && credential != "" {
+		return nil, append(Errors{fmt.Errorf("%w: unsupported scheme", ErrInvalidCredential)}, errs...)
+	}
+	return nil, errs
+}
+
+// CredentialScheme returns the scheme of typed credentials or of a raw Authorization header value
+func CredentialScheme(credential Credential) string {
+	switch c := credential.(type) {
+	case interface{ Scheme() string }:
+		return c.Scheme()
+	case string:
+		scheme, _, _ := strings.Cut(strings.TrimSpace(c), " ")
+		return scheme
+	}
+	return ""
+}
+
+func sortedKeys(s SchemeRouter) []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
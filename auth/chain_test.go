@@ -0,0 +1,115 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func authenticatorMock(scheme string, credential auth.Credential) auth.Authenticator {
+	return auth.AuthenticatorFunc(func(_ context.Context, creds auth.Credential) (auth.Credential, error) {
+		if creds != credential {
+			return nil, &auth.Error{Err: auth.ErrInvalidCredential, Scheme: scheme}
+		}
+		return scheme + " authenticated", nil
+	})
+}
+
+func TestErrors(t *testing.T) {
+	err := auth.Errors{
+		&auth.Error{Err: auth.ErrMissingCredential, Scheme: "Bearer"},
+		fmt.Errorf("%w: my_error", auth.ErrInvalidCredential),
+	}
+	assert.EqualError(t, err, "missing credential; invalid credential: my_error")
+	assert.True(t, errors.Is(err, auth.ErrMissingCredential))
+	assert.True(t, errors.Is(err, auth.ErrInvalidCredential))
+	authErr := &auth.Error{}
+	assert.True(t, errors.As(err, &authErr))
+	assert.Equal(t, "Bearer", authErr.Scheme)
+}
+
+func TestFirstOf(t *testing.T) {
+	authenticator := auth.FirstOf(authenticatorMock("Bearer", "my_token"), authenticatorMock("ApiKey", "my_key"))
+
+	credential, err := authenticator.Authenticate(context.Background(), "my_key")
+	assert.NoError(t, err)
+	assert.Equal(t, "ApiKey authenticated", credential)
+
+	credential, err = authenticator.Authenticate(context.Background(), "my_token")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer authenticated", credential)
+
+	credential, err = authenticator.Authenticate(context.Background(), "other")
+	assert.Nil(t, credential)
+	assert.Equal(t, auth.Errors{
+		&auth.Error{Err: auth.ErrInvalidCredential, Scheme: "Bearer"},
+		&auth.Error{Err: auth.ErrInvalidCredential, Scheme: "ApiKey"},
+	}, err)
+
+	credential, err = auth.FirstOf().Authenticate(context.Background(), "my_token")
+	assert.Nil(t, credential)
+	assert.Equal(t, auth.ErrMissingCredential, err)
+}
+
+func TestAllOf(t *testing.T) {
+	credential, err := auth.AllOf(authenticatorMock("Bearer", "my_token"), authenticatorMock("Other", "my_token")).Authenticate(context.Background(), "my_token")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer authenticated", credential)
+
+	credential, err = auth.AllOf(authenticatorMock("Bearer", "my_token"), authenticatorMock("Other", "other")).Authenticate(context.Background(), "my_token")
+	assert.Nil(t, credential)
+	assert.Equal(t, &auth.Error{Err: auth.ErrInvalidCredential, Scheme: "Other"}, err)
+
+	credential, err = auth.AllOf().Authenticate(context.Background(), "my_token")
+	assert.Nil(t, credential)
+	assert.Equal(t, auth.ErrMissingCredential, err)
+}
+
+func TestSchemeRouter(t *testing.T) {
+	router := auth.SchemeRouter{
+		auth.BearerScheme:            authenticatorMock("Bearer", auth.BearerToken("my_token")),
+		auth.APIKeyScheme:            authenticatorMock("ApiKey", "ApiKey my_key"),
+		auth.ClientCertificateScheme: authenticatorMock("ClientCertificate", auth.SignedClientIdentity("my_identity")),
+	}
+
+	tests := []struct {
+		credential         auth.Credential
+		expectedCredential auth.Credential
+		expectedError      error
+	}{
+		{credential: auth.BearerToken("my_token"), expectedCredential: "Bearer authenticated"},
+		{credential: "apikey my_key", expectedError: &auth.Error{Err: auth.ErrInvalidCredential, Scheme: "ApiKey"}},
+		{credential: "ApiKey my_key", expectedCredential: "ApiKey authenticated"},
+		{credential: auth.SignedClientIdentity("my_identity"), expectedCredential: "ClientCertificate authenticated"},
+		{credential: nil, expectedError: auth.Errors{
+			&auth.Error{Err: auth.ErrInvalidCredential, Scheme: "ApiKey"},
+			&auth.Error{Err: auth.ErrInvalidCredential, Scheme: "Bearer"},
+			&auth.Error{Err: auth.ErrInvalidCredential, Scheme: "ClientCertificate"},
+		}},
+		{credential: "Basic Zm9vOmJhcg==", expectedError: auth.Errors{
+			fmt.Errorf("%w: unsupported scheme", auth.ErrInvalidCredential),
+			&auth.Error{Err: auth.ErrInvalidCredential, Scheme: "ApiKey"},
+			&auth.Error{Err: auth.ErrInvalidCredential, Scheme: "Bearer"},
+			&auth.Error{Err: auth.ErrInvalidCredential, Scheme: "ClientCertificate"},
+		}},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			credential, err := router.Authenticate(context.Background(), tt.credential)
+			assert.Equal(t, tt.expectedCredential, credential)
+			assert.Equal(t, tt.expectedError, err)
+		})
+	}
+}
+
+func TestCredentialScheme(t *testing.T) {
+	assert.Equal(t, "Bearer", auth.CredentialScheme(auth.BearerToken("my_token")))
+	assert.Equal(t, "Basic", auth.CredentialScheme(" Basic Zm9vOmJhcg=="))
+	assert.Equal(t, "ClientCertificate", auth.CredentialScheme(&auth.ClientCertificate{}))
+	assert.Equal(t, "", auth.CredentialScheme(nil))
+	assert.Equal(t, "", auth.CredentialScheme(42))
+}
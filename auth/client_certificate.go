@@ -0,0 +1,361 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// ClientCertificateScheme is the scheme of the client certificate credentials (see SchemeRouter)
+	ClientCertificateScheme = "ClientCertificate"
+	// ClientIdentityHeader carries the signed client identity between internal hops
+	ClientIdentityHeader = "X-Client-Identity"
+)
+
+// ClientIdentity is the identity found in a client certificate
+type ClientIdentity struct {
+	CommonName string   `json:"cn,omitempty"`
+	DNSNames   []string `json:"dns,omitempty"`
+	URIs       []string `json:"uri,omitempty"`
+	// SPIFFEID is the first "spiffe://" URI SAN
+	SPIFFEID string `json:"spiffe,omitempty"`
+}
+
+// Subject returns the SPIFFE ID, or the common name when the identity has none
+func (i *ClientIdentity) Subject() string {
+	if i.SPIFFEID != "" {
+		return i.SPIFFEID
+	}
+	return i.CommonName
+}
+
+func (i *ClientIdentity) Issuer() string {
+	return ""
+}
+
+func (i *ClientIdentity) Scopes() []string {
+	return nil
+}
+
+// Claims returns the identity subject alternative names
+func (i *ClientIdentity) Claims() map[string]interface{} {
+	return map[string]interface{}{
+		"cn":  i.CommonName,
+		"dns": i.DNSNames,
+		"uri": i.URIs,
+	}
+}
+
+func (i *ClientIdentity) ExpiresAt() time.Time {
+	return time.Time{}
+}
+
+func (i *ClientIdentity) AuthMethod() string {
+	return AuthMethodClientIdentity
+}
+
+// NewClientIdentity extracts the identity of a certificate
+func NewClientIdentity(certificate *x509.Certificate) ClientIdentity {
+	identity := ClientIdentity{
+		CommonName: certificate.Subject.CommonName,
+		DNSNames:   certificate.DNSNames,
+	}
+	for _, uri := range certificate.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+		if uri.Scheme == "spiffe" && identity.SPIFFEID == "" {
+			identity.SPIFFEID = uri.String()
+		}
+	}
+	return identity
+}
+
+// ClientCertificate is the credential of a TLS client
+type ClientCertificate struct {
+	ClientIdentity
+	// Chain starts with the client certificate, it is the verified chain when the TLS handshake verified it
+	Chain []*x509.Certificate
+	// Verified reports whether the TLS handshake verified the chain
+	Verified bool
+}
+
+func (c *ClientCertificate) Scheme() string {
+	return ClientCertificateScheme
+}
+
+// ExpiresAt returns the client certificate expiration time
+func (c *ClientCertificate) ExpiresAt() time.Time {
+	if len(c.Chain) == 0 {
+		return time.Time{}
+	}
+	return c.Chain[0].NotAfter
+}
+
+// Issuer returns the distinguished name of the client certificate issuer
+func (c *ClientCertificate) Issuer() string {
+	if len(c.Chain) == 0 {
+		return ""
+	}
+	return c.Chain[0].Issuer.String()
+}
+
+func (c *ClientCertificate) AuthMethod() string {
+	return AuthMethodClientCertificate
+}
+
+// ClientCertificateFromRequest returns the client certificate of a TLS request, nil when the client did not send one
+func ClientCertificateFromRequest(request *http.Request) *ClientCertificate {
+	if request == nil || request.TLS == nil || len(request.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	certificate := &ClientCertificate{Chain: request.TLS.PeerCertificates}
+	if len(request.TLS.VerifiedChains) > 0 {
+		certificate.Chain = request.TLS.VerifiedChains[0]
+		certificate.Verified = true
+	}
+	certificate.ClientIdentity = NewClientIdentity(certificate.Chain[0])
+	return certificate
+}
+
+// SignedClientIdentity is a client identity forwarded by an internal hop (see SignClientIdentity)
+type SignedClientIdentity string
+
+func (s SignedClientIdentity) Scheme() string {
+	return ClientCertificateScheme
+}
+
+type signedClientIdentity struct {
+	ClientIdentity
+	IssuedAt int64 `json:"iat"`
+}
+
+// SignClientIdentity returns the ClientIdentityHeader value: base64url(identity).base64url(HMAC-SHA256)
+func SignClientIdentity(key []byte, identity ClientIdentity) (SignedClientIdentity, error) {
+	payload, err := json.Marshal(signedClientIdentity{ClientIdentity: identity, IssuedAt: time.Now().Unix()})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return SignedClientIdentity(encoded + "." + base64.RawURLEncoding.EncodeToString(identityMAC(key, encoded))), nil
+}
+
+// VerifyClientIdentity checks the signature and the age of a signed identity
+func VerifyClientIdentity(key []byte, signed SignedClientIdentity, maxAge time.Duration) (*ClientIdentity, error) {
+	parts := strings.Split(string(signed), ".")
+	if len(parts) != 2 {
+		return nil, errors.New("malformed client identity")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, identityMAC(key, parts[0])) {
+		return nil, errors.New("invalid client identity signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("malformed client identity")
+	}
+	identity := &signedClientIdentity{}
+	if err := json.Unmarshal(payload, identity); err != nil {
+		return nil, errors.New("malformed client identity")
+	}
+	if age := time.Since(time.Unix(identity.IssuedAt, 0)); age > maxAge || age < -maxAge {
+		return nil, errors.New("client identity expired")
+	}
+	return &identity.ClientIdentity, nil
+}
+
+func identityMAC(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// ClientCertificateAuthenticator authenticates *ClientCertificate and SignedClientIdentity credentials
+// the identity must match one of the allowlists (when configured) and the verifier
+// it returns the *ClientCertificate, or the *ClientIdentity of a signed identity, as authenticated credential
+type ClientCertificateAuthenticator struct {
+	config *ClientCertificateConfig
+}
+
+func (c *ClientCertificateAuthenticator) Authenticate(_ context.Context, credential Credential) (Credential, error) {
+	switch cred := credential.(type) {
+	case *ClientCertificate:
+		if cred == nil || len(cred.Chain) == 0 {
+			return nil, ErrMissingCredential
+		}
+		if err := c.verifyChain(cred); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCredential, err)
+		}
+		if err := c.verifyIdentity(&cred.ClientIdentity); err != nil {
+			return nil, err
+		}
+		return cred, nil
+	case SignedClientIdentity:
+		if c.config.IdentityKey == nil {
+			return nil, fmt.Errorf("%w: signed client identity not accepted", ErrInvalidCredential)
+		}
+		identity, err := VerifyClientIdentity(c.config.IdentityKey, cred, c.config.IdentityMaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCredential, err)
+		}
+		if err := c.verifyIdentity(identity); err != nil {
+			return nil, err
+		}
+		return identity, nil
+	}
+	return nil, ErrMissingCredential
+}
+
+func (c *ClientCertificateAuthenticator) verifyChain(certificate *ClientCertificate) error {
+	if c.config.Roots == nil {
+		if !certificate.Verified {
+			return errors.New("unverified client certificate")
+		}
+		return nil
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certificate.Chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certificate.Chain[0].Verify(x509.VerifyOptions{
+		Roots:         c.config.Roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
+
+func (c *ClientCertificateAuthenticator) verifyIdentity(identity *ClientIdentity) error {
+	config := c.config
+	if len(config.CommonNames)+len(config.DNSNames)+len(config.URIs)+len(config.SPIFFEIDs) > 0 &&
+		!contains(config.CommonNames, identity.CommonName) &&
+		!containsAny(config.DNSNames, identity.DNSNames) &&
+		!containsAny(config.URIs, identity.URIs) &&
+		!contains(config.SPIFFEIDs, identity.SPIFFEID) {
+		return fmt.Errorf("%w: client identity not allowed", ErrInvalidCredential)
+	}
+	if config.Verifier != nil {
+		if err := config.Verifier(identity); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidCredential, err)
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(values []string, candidates []string) bool {
+	for _, candidate := range candidates {
+		if contains(values, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewClientCertificateAuthenticator creates a ClientCertificateAuthenticator
+func NewClientCertificateAuthenticator(options ...ClientCertificateOption) *ClientCertificateAuthenticator {
+	return &ClientCertificateAuthenticator{
+		config: NewClientCertificateConfig(options...),
+	}
+}
+
+type ClientCertificateConfig struct {
+	// Roots verifies the chain when the TLS handshake did not (ex: tls.RequestClientCert), otherwise the chain must be verified
+	Roots *x509.CertPool
+	// allowlists, the identity is accepted when it matches one of them, empty allowlists accept every identity
+	CommonNames []string
+	DNSNames    []string
+	URIs        []string
+	SPIFFEIDs   []string
+	// Verifier is a custom identity check
+	Verifier func(*ClientIdentity) error
+	// IdentityKey verifies the SignedClientIdentity, they are rejected when it is nil
+	IdentityKey    []byte
+	IdentityMaxAge time.Duration
+}
+
+func (c *ClientCertificateConfig) apply(options ...ClientCertificateOption) *ClientCertificateConfig {
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// NewClientCertificateConfig returns a new client certificate configuration with all options applied
+func NewClientCertificateConfig(options ...ClientCertificateOption) *ClientCertificateConfig {
+	config := &ClientCertificateConfig{
+		IdentityMaxAge: time.Minute,
+	}
+	return config.apply(options...)
+}
+
+// ClientCertificateOption defines a ClientCertificateAuthenticator configuration option
+type ClientCertificateOption func(*ClientCertificateConfig)
+
+// WithRoots will configure Roots option
+func WithRoots(roots *x509.CertPool) ClientCertificateOption {
+	return func(config *ClientCertificateConfig) {
+		config.Roots = roots
+	}
+}
+
+// WithAllowedCommonNames will configure CommonNames option
+func WithAllowedCommonNames(commonNames ...string) ClientCertificateOption {
+	return func(config *ClientCertificateConfig) {
+		config.CommonNames = commonNames
+	}
+}
+
+// WithAllowedDNSNames will configure DNSNames option
+func WithAllowedDNSNames(dnsNames ...string) ClientCertificateOption {
+	return func(config *ClientCertificateConfig) {
+		config.DNSNames = dnsNames
+	}
+}
+
+// WithAllowedURIs will configure URIs option
+func WithAllowedURIs(uris ...string) ClientCertificateOption {
+	return func(config *ClientCertificateConfig) {
+		config.URIs = uris
+	}
+}
+
+// WithAllowedSPIFFEIDs will configure SPIFFEIDs option
+func WithAllowedSPIFFEIDs(spiffeIDs ...string) ClientCertificateOption {
+	return func(config *ClientCertificateConfig) {
+		config.SPIFFEIDs = spiffeIDs
+	}
+}
+
+// WithIdentityVerifier will configure Verifier option
+func WithIdentityVerifier(verifier func(*ClientIdentity) error) ClientCertificateOption {
+	return func(config *ClientCertificateConfig) {
+		config.Verifier = verifier
+	}
+}
+
+// WithSignedIdentity will configure IdentityKey and IdentityMaxAge options
+func WithSignedIdentity(key []byte, maxAge time.Duration) ClientCertificateOption {
+	return func(config *ClientCertificateConfig) {
+		config.IdentityKey = key
+		config.IdentityMaxAge = maxAge
+	}
+}
@@ -0,0 +1,164 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCertificate(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return certificate, key
+}
+
+func newClientCertificate(t *testing.T) (*x509.Certificate, *x509.Certificate) {
+	ca, caKey := newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "my_ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	spiffeID, _ := url.Parse("spiffe://example.org/my_service")
+	client, _ := newCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "my_client"},
+		DNSNames:    []string{"my-client.example.org"},
+		URIs:        []*url.URL{spiffeID},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+	return ca, client
+}
+
+func TestClientCertificateFromRequest(t *testing.T) {
+	_, client := newClientCertificate(t)
+	request := httptest.NewRequest(http.MethodGet, "https://fake-addr", nil)
+	assert.Nil(t, auth.ClientCertificateFromRequest(request))
+
+	request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}
+	assert.Equal(t, &auth.ClientCertificate{
+		ClientIdentity: auth.ClientIdentity{
+			CommonName: "my_client",
+			DNSNames:   []string{"my-client.example.org"},
+			URIs:       []string{"spiffe://example.org/my_service"},
+			SPIFFEID:   "spiffe://example.org/my_service",
+		},
+		Chain: []*x509.Certificate{client},
+	}, auth.ClientCertificateFromRequest(request))
+
+	request.TLS.VerifiedChains = [][]*x509.Certificate{{client}}
+	assert.True(t, auth.ClientCertificateFromRequest(request).Verified)
+}
+
+func TestClientCertificateAuthenticator(t *testing.T) {
+	ca, client := newClientCertificate(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	unverified := &auth.ClientCertificate{ClientIdentity: auth.NewClientIdentity(client), Chain: []*x509.Certificate{client}}
+	verified := &auth.ClientCertificate{ClientIdentity: auth.NewClientIdentity(client), Chain: []*x509.Certificate{client, ca}, Verified: true}
+
+	tests := []struct {
+		options       []auth.ClientCertificateOption
+		credential    auth.Credential
+		expectedError string
+	}{
+		{credential: nil, expectedError: "missing credential"},
+		{credential: "my_credential", expectedError: "missing credential"},
+		{credential: unverified, expectedError: "invalid credential: unverified client certificate"},
+		{credential: verified},
+		{options: []auth.ClientCertificateOption{auth.WithRoots(roots)}, credential: unverified},
+		{options: []auth.ClientCertificateOption{auth.WithRoots(x509.NewCertPool())}, credential: unverified, expectedError: "invalid credential: x509: certificate signed by unknown authority"},
+		{options: []auth.ClientCertificateOption{auth.WithAllowedCommonNames("my_client")}, credential: verified},
+		{options: []auth.ClientCertificateOption{auth.WithAllowedCommonNames("other")}, credential: verified, expectedError: "invalid credential: client identity not allowed"},
+		{options: []auth.ClientCertificateOption{auth.WithAllowedCommonNames("other"), auth.WithAllowedDNSNames("my-client.example.org")}, credential: verified},
+		{options: []auth.ClientCertificateOption{auth.WithAllowedURIs("spiffe://example.org/my_service")}, credential: verified},
+		{options: []auth.ClientCertificateOption{auth.WithAllowedSPIFFEIDs("spiffe://example.org/my_service")}, credential: verified},
+		{options: []auth.ClientCertificateOption{auth.WithAllowedSPIFFEIDs("spiffe://example.org/other")}, credential: verified, expectedError: "invalid credential: client identity not allowed"},
+		{options: []auth.ClientCertificateOption{auth.WithIdentityVerifier(func(identity *auth.ClientIdentity) error {
+			return errors.New("my_verifier_error")
+		})}, credential: verified, expectedError: "invalid credential: my_verifier_error"},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			credential, err := auth.NewClientCertificateAuthenticator(tt.options...).Authenticate(context.Background(), tt.credential)
+			if tt.expectedError != "" {
+				assert.Nil(t, credential)
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.credential, credential)
+		})
+	}
+}
+
+func TestClientCertificateAuthenticator_SignedIdentity(t *testing.T) {
+	identity := auth.ClientIdentity{CommonName: "my_client", SPIFFEID: "spiffe://example.org/my_service"}
+	signed, err := auth.SignClientIdentity([]byte("my_key"), identity)
+	assert.NoError(t, err)
+
+	credential, err := auth.NewClientCertificateAuthenticator().Authenticate(context.Background(), signed)
+	assert.Nil(t, credential)
+	assert.EqualError(t, err, "invalid credential: signed client identity not accepted")
+
+	authenticator := auth.NewClientCertificateAuthenticator(auth.WithSignedIdentity([]byte("my_key"), time.Minute))
+	credential, err = authenticator.Authenticate(context.Background(), signed)
+	assert.NoError(t, err)
+	assert.Equal(t, &identity, credential)
+
+	credential, err = authenticator.Authenticate(context.Background(), signed+"x")
+	assert.Nil(t, credential)
+	assert.EqualError(t, err, "invalid credential: invalid client identity signature")
+
+	credential, err = auth.NewClientCertificateAuthenticator(
+		auth.WithSignedIdentity([]byte("my_key"), time.Minute),
+		auth.WithAllowedCommonNames("other"),
+	).Authenticate(context.Background(), signed)
+	assert.Nil(t, credential)
+	assert.EqualError(t, err, "invalid credential: client identity not allowed")
+}
+
+func TestVerifyClientIdentity(t *testing.T) {
+	signed, err := auth.SignClientIdentity([]byte("my_key"), auth.ClientIdentity{CommonName: "my_client"})
+	assert.NoError(t, err)
+
+	identity, err := auth.VerifyClientIdentity([]byte("my_key"), signed, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, &auth.ClientIdentity{CommonName: "my_client"}, identity)
+
+	identity, err = auth.VerifyClientIdentity([]byte("other_key"), signed, time.Minute)
+	assert.Nil(t, identity)
+	assert.EqualError(t, err, "invalid client identity signature")
+
+	identity, err = auth.VerifyClientIdentity([]byte("my_key"), signed, -time.Second)
+	assert.Nil(t, identity)
+	assert.EqualError(t, err, "client identity expired")
+
+	identity, err = auth.VerifyClientIdentity([]byte("my_key"), "malformed", time.Minute)
+	assert.Nil(t, identity)
+	assert.EqualError(t, err, "malformed client identity")
+}
@@ -0,0 +1,541 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DigestScheme = "Digest"
+
+	DigestMD5        = "MD5"
+	DigestMD5Sess    = "MD5-sess"
+	DigestSHA256     = "SHA-256"
+	DigestSHA256Sess = "SHA-256-sess"
+)
+
+var digestHashes = map[string]func() hash.Hash{
+	DigestMD5:        md5.New,
+	DigestMD5Sess:    md5.New,
+	DigestSHA256:     sha256.New,
+	DigestSHA256Sess: sha256.New,
+}
+
+// DigestCredential is a "Digest <params>" credential (RFC 7616 section 3.4)
+type DigestCredential struct {
+	Username  string
+	Realm     string
+	Nonce     string
+	URI       string
+	Response  string
+	Algorithm string
+	Cnonce    string
+	Opaque    string
+	QOP       string
+	NC        string
+	// Method is the request method, it is not sent in the header but it is part of the response computation
+	Method string
+	// RequestURI is the request target, it is not sent in the header but the URI must match it (RFC 7616 section 3.4.6)
+	RequestURI string
+}
+
+// SingleUse prevents the caching of the credential, each nonce count must be verified once (see CredentialKey)
+func (c DigestCredential) SingleUse() bool {
+	return true
+}
+
+func (c DigestCredential) Scheme() string {
+	return DigestScheme
+}
+
+func (c DigestCredential) Parameters() string {
+	return formatParams(map[string]string{
+		"username":  c.Username,
+		"realm":     c.Realm,
+		"nonce":     c.Nonce,
+		"uri":       c.URI,
+		"response":  c.Response,
+		"algorithm": c.Algorithm,
+		"cnonce":    c.Cnonce,
+		"opaque":    c.Opaque,
+		"qop":       c.QOP,
+		"nc":        c.NC,
+	}, "algorithm", "qop", "nc")
+}
+
+// DigestChallenge is a "WWW-Authenticate: Digest <params>" challenge (RFC 7616 section 3.3)
+type DigestChallenge struct {
+	Realm     string
+	Nonce     string
+	Opaque    string
+	Algorithm string
+	QOP       string
+	Stale     bool
+}
+
+// ParseDigestChallenge parses a WWW-Authenticate header value
+func ParseDigestChallenge(header string) (*DigestChallenge, error) {
+	scheme, parameters, _ := strings.Cut(strings.TrimSpace(header), " ")
+	if !strings.EqualFold(scheme, DigestScheme) {
+		return nil, errors.New("not a digest challenge")
+	}
+	params, err := ParseParams(parameters)
+	if err != nil {
+		return nil, err
+	}
+	challenge := &DigestChallenge{
+		Realm:     params["realm"],
+		Nonce:     params["nonce"],
+		Opaque:    params["opaque"],
+		Algorithm: params["algorithm"],
+		QOP:       params["qop"],
+		Stale:     strings.EqualFold(params["stale"], "true"),
+	}
+	if challenge.Nonce == "" {
+		return nil, errors.New("malformed digest challenge: missing nonce")
+	}
+	if challenge.Algorithm == "" {
+		challenge.Algorithm = DigestMD5
+	}
+	return challenge, nil
+}
+
+// Supported reports whether the challenge algorithm and quality of protection can be answered
+func (c *DigestChallenge) Supported() bool {
+	_, ok := digestHashes[c.Algorithm]
+	return ok && (c.QOP == "" || hasQOPAuth(c.QOP))
+}
+
+// Authorize computes the credential answering the challenge
+func (c *DigestChallenge) Authorize(method string, uri string, username string, password string, cnonce string, nc uint32) (DigestCredential, error) {
+	if !c.Supported() {
+		return DigestCredential{}, fmt.Errorf("unsupported digest algorithm %q", c.Algorithm)
+	}
+	credential := DigestCredential{
+		Username:   username,
+		Realm:      c.Realm,
+		Nonce:      c.Nonce,
+		URI:        uri,
+		Algorithm:  c.Algorithm,
+		Opaque:     c.Opaque,
+		Method:     method,
+		RequestURI: uri,
+	}
+	if c.QOP != "" {
+		credential.QOP = "auth"
+		credential.Cnonce = cnonce
+		credential.NC = fmt.Sprintf("%08x", nc)
+	}
+	credential.Response = digestResponse(credential, digestHA1(c.Algorithm, username, c.Realm, password))
+	return credential, nil
+}
+
+// digestURIMatches checks the digest uri designates the request target, the uri can be in absolute form
+func digestURIMatches(c DigestCredential) bool {
+	if c.URI == "" || c.RequestURI == "" {
+		return false
+	}
+	if c.URI == c.RequestURI {
+		return true
+	}
+	u, err := url.Parse(c.URI)
+	if err != nil {
+		return false
+	}
+	target, err := url.Parse(c.RequestURI)
+	if err != nil {
+		return false
+	}
+	if u.IsAbs() && target.IsAbs() && !strings.EqualFold(u.Host, target.Host) {
+		return false
+	}
+	return u.RequestURI() == target.RequestURI()
+}
+
+func hasQOPAuth(qop string) bool {
+	for _, value := range strings.Split(qop, ",") {
+		if strings.TrimSpace(value) == "auth" {
+			return true
+		}
+	}
+	return false
+}
+
+func digestHash(algorithm string, value string) string {
+	h := digestHashes[algorithm]()
+	h.Write([]byte(value))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func digestHA1(algorithm string, username string, realm string, password string) string {
+	return digestHash(algorithm, username+":"+realm+":"+password)
+}
+
+func digestResponse(c DigestCredential, ha1 string) string {
+	if strings.HasSuffix(c.Algorithm, "-sess") {
+		ha1 = digestHash(c.Algorithm, ha1+":"+c.Nonce+":"+c.Cnonce)
+	}
+	ha2 := digestHash(c.Algorithm, c.Method+":"+c.URI)
+	if c.QOP == "" {
+		return digestHash(c.Algorithm, ha1+":"+c.Nonce+":"+ha2)
+	}
+	return digestHash(c.Algorithm, ha1+":"+c.Nonce+":"+c.NC+":"+c.Cnonce+":"+c.QOP+":"+ha2)
+}
+
+// DigestPasswordProvider returns the plain text password of a user, digest authentication needs it to compute the response
+type DigestPasswordProvider interface {
+	Password(username string) (string, bool)
+}
+
+// DigestPasswords are the plain text passwords by username used by the DigestAuthenticator
+type DigestPasswords map[string]string
+
+func (d DigestPasswords) Password(username string) (string, bool) {
+	password, ok := d[username]
+	return password, ok
+}
+
+// DigestAuthenticator authenticates DigestCredential, the credential Method and RequestURI must be set (see middleware.DigestCredentialFinder)
+// it generates the nonces, rejects the replayed nonce counts and returns the UserPrincipal as authenticated credential
+type DigestAuthenticator struct {
+	realm     string
+	passwords DigestPasswordProvider
+	config    *DigestConfig
+
+	// nonce counts of the nonces used by an authenticated client, the nonces themselves are stateless
+	mutex     sync.Mutex
+	nonces    map[string]*digestNonce
+	lastSweep time.Time
+}
+
+type digestNonce struct {
+	createdAt time.Time
+	nc        uint64
+}
+
+const (
+	nonceDataSize = 16
+	nonceSize     = nonceDataSize + sha256.Size
+)
+
+func (d *DigestAuthenticator) Authenticate(_ context.Context, credential Credential) (Credential, error) {
+	if raw, ok := credential.(string); ok {
+		parsed, err := ParseCredential(raw)
+		if err != nil {
+			return nil, d.challenges(err, false)
+		}
+		credential = parsed
+	}
+	digest, ok := credential.(DigestCredential)
+	if !ok {
+		return nil, d.challenges(ErrMissingCredential, false)
+	}
+	if _, ok := digestHashes[digest.Algorithm]; !ok || !contains(d.config.Algorithms, digest.Algorithm) {
+		return nil, d.challenges(fmt.Errorf("%w: unsupported algorithm", ErrInvalidCredential), false)
+	}
+	if digest.Realm != d.realm || digest.Opaque != d.config.Opaque || (digest.QOP != "" && digest.QOP != "auth") {
+		return nil, d.challenges(ErrInvalidCredential, false)
+	}
+	if digest.QOP == "" && !d.config.AllowNoQOP {
+		return nil, d.challenges(ErrInvalidCredential, false)
+	}
+	if !digestURIMatches(digest) {
+		return nil, d.challenges(fmt.Errorf("%w: uri mismatch", ErrInvalidCredential), false)
+	}
+
+	password, ok := d.passwords.Password(digest.Username)
+	expected := digestResponse(digest, digestHA1(digest.Algorithm, digest.Username, d.realm, password))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(digest.Response)) != 1 || !ok {
+		return nil, d.challenges(ErrInvalidCredential, false)
+	}
+	if stale, err := d.useNonce(digest); err != nil {
+		return nil, d.challenges(err, stale)
+	}
+	return UserPrincipal{Username: digest.Username, Method: AuthMethodDigest}, nil
+}
+
+// useNonce checks the nonce signature and age, and that its count was never used
+// the nonce count is only stored once the client is authenticated
+func (d *DigestAuthenticator) useNonce(digest DigestCredential) (stale bool, err error) {
+	createdAt, ok := d.nonceCreatedAt(digest.Nonce)
+	if !ok {
+		return false, fmt.Errorf("%w: invalid nonce", ErrInvalidCredential)
+	}
+	now := time.Now()
+	if now.Sub(createdAt) > d.config.NonceTTL {
+		return true, fmt.Errorf("%w: stale nonce", ErrInvalidCredential)
+	}
+	nc := uint64(1)
+	if digest.QOP != "" {
+		if nc, err = strconv.ParseUint(digest.NC, 16, 32); err != nil {
+			return false, ErrInvalidCredential
+		}
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	// the expired nonce counts are swept once per TTL
+	if now.Sub(d.lastSweep) > d.config.NonceTTL {
+		for key, n := range d.nonces {
+			if now.Sub(n.createdAt) > d.config.NonceTTL {
+				delete(d.nonces, key)
+			}
+		}
+		d.lastSweep = now
+	}
+	nonce, ok := d.nonces[digest.Nonce]
+	if !ok {
+		nonce = &digestNonce{createdAt: createdAt}
+		d.nonces[digest.Nonce] = nonce
+	}
+	// without qop the nonce can only be used once
+	if nc <= nonce.nc || (digest.QOP == "" && nonce.nc > 0) {
+		return false, fmt.Errorf("%w: replayed nonce count", ErrInvalidCredential)
+	}
+	nonce.nc = nc
+	return false, nil
+}
+
+// newNonce returns a stateless nonce: base64url(timestamp | random | HMAC-SHA256(timestamp | random))
+func (d *DigestAuthenticator) newNonce() string {
+	b := make([]byte, nonceDataSize, nonceSize)
+	binary.BigEndian.PutUint64(b, uint64(time.Now().UnixNano()))
+	_, _ = rand.Read(b[8:nonceDataSize])
+	return base64.RawURLEncoding.EncodeToString(append(b, d.nonceMAC(b)...))
+}
+
+// nonceCreatedAt returns the creation time of a nonce generated by newNonce, ok is false when the nonce is forged
+func (d *DigestAuthenticator) nonceCreatedAt(nonce string) (time.Time, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(nonce)
+	if err != nil || len(b) != nonceSize || !hmac.Equal(b[nonceDataSize:], d.nonceMAC(b[:nonceDataSize])) {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(b))), true
+}
+
+func (d *DigestAuthenticator) nonceMAC(data []byte) []byte {
+	mac := hmac.New(sha256.New, d.config.NonceKey)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// challenges returns one challenge per algorithm, the client picks the strongest one it supports
+func (d *DigestAuthenticator) challenges(err error, stale bool) error {
+	nonce := d.newNonce()
+	errs := make(Errors, 0, len(d.config.Algorithms))
+	for _, algorithm := range d.config.Algorithms {
+		params := map[string]string{
+			"nonce":     nonce,
+			"algorithm": algorithm,
+			"qop":       "auth",
+		}
+		if d.config.Opaque != "" {
+			params["opaque"] = d.config.Opaque
+		}
+		if stale {
+			params["stale"] = "true"
+		}
+		errs = append(errs, &Error{Err: err, Scheme: DigestScheme, Realm: d.realm, Params: params})
+	}
+	return errs
+}
+
+// NewDigestAuthenticator creates a DigestAuthenticator with the plain text password provider
+func NewDigestAuthenticator(realm string, passwords DigestPasswordProvider, options ...DigestOption) *DigestAuthenticator {
+	config := NewDigestConfig(options...)
+	if len(config.NonceKey) == 0 {
+		config.NonceKey = make([]byte, 32)
+		_, _ = rand.Read(config.NonceKey)
+	}
+	return &DigestAuthenticator{
+		realm:     realm,
+		passwords: passwords,
+		config:    config,
+		nonces:    map[string]*digestNonce{},
+	}
+}
+
+type DigestConfig struct {
+	// Algorithms offered in the challenges, by order of preference
+	Algorithms []string
+	// NonceTTL is the lifetime of a nonce, an expired nonce gets a stale challenge
+	NonceTTL time.Duration
+	// Opaque is sent in the challenge and must be sent back by the client
+	Opaque string
+	// AllowNoQOP accepts the legacy RFC 2069 responses without qop (each nonce can then be used once)
+	AllowNoQOP bool
+	// NonceKey signs the nonces, a random key is generated when it is empty
+	// instances sharing the key accept each other nonces
+	NonceKey []byte
+}
+
+func (c *DigestConfig) apply(options ...DigestOption) *DigestConfig {
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// NewDigestConfig returns a new digest configuration with all options applied
+func NewDigestConfig(options ...DigestOption) *DigestConfig {
+	config := &DigestConfig{
+		Algorithms: []string{DigestSHA256, DigestMD5},
+		NonceTTL:   5 * time.Minute,
+	}
+	return config.apply(options...)
+}
+
+// DigestOption defines a DigestAuthenticator configuration option
+type DigestOption func(*DigestConfig)
+
+// WithDigestAlgorithms will configure Algorithms option
+func WithDigestAlgorithms(algorithms ...string) DigestOption {
+	return func(config *DigestConfig) {
+		config.Algorithms = algorithms
+	}
+}
+
+// WithNonceTTL will configure NonceTTL option
+func WithNonceTTL(nonceTTL time.Duration) DigestOption {
+	return func(config *DigestConfig) {
+		config.NonceTTL = nonceTTL
+	}
+}
+
+// WithOpaque will configure Opaque option
+func WithOpaque(opaque string) DigestOption {
+	return func(config *DigestConfig) {
+		config.Opaque = opaque
+	}
+}
+
+// WithAllowNoQOP will configure AllowNoQOP option
+func WithAllowNoQOP(allowNoQOP bool) DigestOption {
+	return func(config *DigestConfig) {
+		config.AllowNoQOP = allowNoQOP
+	}
+}
+
+// WithNonceKey will configure NonceKey option
+func WithNonceKey(nonceKey []byte) DigestOption {
+	return func(config *DigestConfig) {
+		config.NonceKey = nonceKey
+	}
+}
+
+func parseDigestCredential(parameters string) (Credential, error) {
+	params, err := ParseParams(parameters)
+	if err != nil {
+		return nil, ErrInvalidCredential
+	}
+	credential := DigestCredential{
+		Username:  params["username"],
+		Realm:     params["realm"],
+		Nonce:     params["nonce"],
+		URI:       params["uri"],
+		Response:  params["response"],
+		Algorithm: params["algorithm"],
+		Cnonce:    params["cnonce"],
+		Opaque:    params["opaque"],
+		QOP:       params["qop"],
+		NC:        params["nc"],
+	}
+	if credential.Username == "" || credential.Nonce == "" || credential.Response == "" {
+		return nil, ErrInvalidCredential
+	}
+	if credential.Algorithm == "" {
+		credential.Algorithm = DigestMD5
+	}
+	return credential, nil
+}
+
+// ParseParams parses a comma separated list of auth-param: key=token or key="quoted string"
+// keys are lower cased
+func ParseParams(s string) (map[string]string, error) {
+	params := map[string]string{}
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return params, nil
+		}
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, errors.New("malformed auth params")
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimLeft(rest, " \t")
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			if i >= len(rest) {
+				return nil, errors.New("malformed auth params: unterminated quoted string")
+			}
+			value, s = b.String(), rest[i+1:]
+		} else {
+			end := strings.IndexByte(rest, ',')
+			if end < 0 {
+				end = len(rest)
+			}
+			value, s = strings.TrimSpace(rest[:end]), rest[end:]
+		}
+		params[key] = value
+	}
+}
+
+// formatParams writes the non empty params sorted by key, the tokens params are not quoted
+func formatParams(params map[string]string, tokens ...string) string {
+	keys := make([]string, 0, len(params))
+	for key, value := range params {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if contains(tokens, key) {
+			parts = append(parts, key+"="+params[key])
+			continue
+		}
+		parts = append(parts, key+"="+quoteString(params[key]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// quoteString returns the value as a RFC 7230 quoted-string, only the double quote and the backslash are escaped
+func quoteString(value string) string {
+	var builder strings.Builder
+	builder.Grow(len(value) + 2)
+	builder.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		if value[i] == '"' || value[i] == '\\' {
+			builder.WriteByte('\\')
+		}
+		builder.WriteByte(value[i])
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
@@ -0,0 +1,203 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 7616 section 3.9.1 example
+func TestDigestChallenge_Authorize(t *testing.T) {
+	challenge, err := auth.ParseDigestChallenge(`Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=SHA-256, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`)
+	assert.NoError(t, err)
+	assert.True(t, challenge.Supported())
+
+	credential, err := challenge.Authorize("GET", "/dir/index.html", "Mufasa", "Circle of Life", "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1", credential.Response)
+	assert.Equal(t, "00000001", credential.NC)
+
+	challenge.Algorithm = auth.DigestMD5
+	credential, err = challenge.Authorize("GET", "/dir/index.html", "Mufasa", "Circle of Life", "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "8ca523f5e9506fed4657c9700eebdbec", credential.Response)
+
+	parsed, err := auth.ParseCredential(auth.FormatCredential(credential))
+	assert.NoError(t, err)
+	credential.Method, credential.RequestURI = "", ""
+	assert.Equal(t, credential, parsed)
+}
+
+func TestParseDigestChallenge_Error(t *testing.T) {
+	_, err := auth.ParseDigestChallenge(`Basic realm="my_realm"`)
+	assert.EqualError(t, err, "not a digest challenge")
+	_, err = auth.ParseDigestChallenge(`Digest realm="my_realm"`)
+	assert.EqualError(t, err, "malformed digest challenge: missing nonce")
+	_, err = auth.ParseDigestChallenge(`Digest realm="my_realm`)
+	assert.EqualError(t, err, "malformed auth params: unterminated quoted string")
+}
+
+func TestParseParams(t *testing.T) {
+	params, err := auth.ParseParams(`Realm="my \"realm\", with comma", qop=auth ,nc=00000001`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"realm": `my "realm", with comma`, "qop": "auth", "nc": "00000001"}, params)
+}
+
+func challengeOf(t *testing.T, err error, algorithm string) *auth.DigestChallenge {
+	for _, header := range auth.Challenges(err) {
+		challenge, parseErr := auth.ParseDigestChallenge(header)
+		assert.NoError(t, parseErr)
+		if challenge.Algorithm == algorithm {
+			return challenge
+		}
+	}
+	t.Fatalf("no %s challenge", algorithm)
+	return nil
+}
+
+func TestDigestAuthenticator(t *testing.T) {
+	authenticator := auth.NewDigestAuthenticator("my_realm", auth.DigestPasswords{"foo": "my_password"}, auth.WithOpaque("my_opaque"))
+
+	credential, err := authenticator.Authenticate(context.Background(), nil)
+	assert.Nil(t, credential)
+	assert.True(t, errors.Is(err, auth.ErrMissingCredential))
+	challenges := auth.Challenges(err)
+	assert.Len(t, challenges, 2)
+	assert.Regexp(t, `^Digest realm="my_realm", algorithm=SHA-256, nonce="[\w-]+", opaque="my_opaque", qop="auth"$`, challenges[0])
+	assert.Regexp(t, `^Digest realm="my_realm", algorithm=MD5, nonce="[\w-]+", opaque="my_opaque", qop="auth"$`, challenges[1])
+
+	// both challenges share the same nonce and nonce count
+	for i, algorithm := range []string{auth.DigestSHA256, auth.DigestMD5} {
+		nc := uint32(2*i + 1)
+		challenge := challengeOf(t, err, algorithm)
+		digest, authorizeErr := challenge.Authorize("GET", "/my_path", "foo", "my_password", "my_cnonce", nc)
+		assert.NoError(t, authorizeErr)
+		credential, authErr := authenticator.Authenticate(context.Background(), digest)
+		assert.NoError(t, authErr)
+		assert.Equal(t, auth.UserPrincipal{Username: "foo", Method: auth.AuthMethodDigest}, credential)
+
+		// the nonce count cannot be replayed
+		credential, authErr = authenticator.Authenticate(context.Background(), digest)
+		assert.Nil(t, credential)
+		assert.EqualError(t, authErr, "invalid credential: replayed nonce count; invalid credential: replayed nonce count")
+
+		digest, _ = challenge.Authorize("GET", "/my_path", "foo", "my_password", "my_cnonce", nc+1)
+		credential, authErr = authenticator.Authenticate(context.Background(), digest)
+		assert.NoError(t, authErr)
+		assert.Equal(t, auth.UserPrincipal{Username: "foo", Method: auth.AuthMethodDigest}, credential)
+	}
+
+	challenge := challengeOf(t, err, auth.DigestSHA256)
+	digest, _ := challenge.Authorize("GET", "/my_path", "foo", "wrong_password", "my_cnonce", 5)
+	credential, err = authenticator.Authenticate(context.Background(), digest)
+	assert.Nil(t, credential)
+	assert.EqualError(t, err, "invalid credential; invalid credential")
+
+	digest, _ = challenge.Authorize("GET", "/my_path", "bar", "", "my_cnonce", 5)
+	_, err = authenticator.Authenticate(context.Background(), digest)
+	assert.EqualError(t, err, "invalid credential; invalid credential")
+
+	challenge.Algorithm = auth.DigestSHA256Sess
+	digest, _ = challenge.Authorize("GET", "/my_path", "foo", "my_password", "my_cnonce", 5)
+	_, err = authenticator.Authenticate(context.Background(), digest)
+	assert.EqualError(t, err, "invalid credential: unsupported algorithm; invalid credential: unsupported algorithm")
+}
+
+func TestDigestAuthenticator_StaleNonce(t *testing.T) {
+	authenticator := auth.NewDigestAuthenticator("my_realm", auth.DigestPasswords{"foo": "my_password"}, auth.WithNonceTTL(10*time.Millisecond), auth.WithDigestAlgorithms(auth.DigestSHA256))
+
+	_, err := authenticator.Authenticate(context.Background(), "")
+	digest, _ := challengeOf(t, err, auth.DigestSHA256).Authorize("GET", "/my_path", "foo", "my_password", "my_cnonce", 1)
+
+	time.Sleep(20 * time.Millisecond)
+	credential, err := authenticator.Authenticate(context.Background(), digest)
+	assert.Nil(t, credential)
+	assert.EqualError(t, err, "invalid credential: stale nonce")
+	assert.True(t, challengeOf(t, err, auth.DigestSHA256).Stale)
+}
+
+func TestDigestAuthenticator_StatelessNonce(t *testing.T) {
+	key := []byte("my_nonce_key")
+	authenticator := auth.NewDigestAuthenticator("my_realm", auth.DigestPasswords{"foo": "my_password"}, auth.WithNonceKey(key), auth.WithDigestAlgorithms(auth.DigestSHA256))
+	otherInstance := auth.NewDigestAuthenticator("my_realm", auth.DigestPasswords{"foo": "my_password"}, auth.WithNonceKey(key), auth.WithDigestAlgorithms(auth.DigestSHA256))
+
+	_, err := authenticator.Authenticate(context.Background(), nil)
+	challenge := challengeOf(t, err, auth.DigestSHA256)
+
+	// the nonce is accepted by the instances sharing the key
+	digest, _ := challenge.Authorize("GET", "/my_path", "foo", "my_password", "my_cnonce", 1)
+	_, err = otherInstance.Authenticate(context.Background(), digest)
+	assert.NoError(t, err)
+
+	challenge.Nonce = "forged"
+	digest, _ = challenge.Authorize("GET", "/my_path", "foo", "my_password", "my_cnonce", 1)
+	_, err = authenticator.Authenticate(context.Background(), digest)
+	assert.EqualError(t, err, "invalid credential: invalid nonce")
+
+	_, err = auth.NewDigestAuthenticator("my_realm", auth.DigestPasswords{"foo": "my_password"}, auth.WithDigestAlgorithms(auth.DigestSHA256)).Authenticate(context.Background(), nil)
+	digest, _ = challengeOf(t, err, auth.DigestSHA256).Authorize("GET", "/my_path", "foo", "my_password", "my_cnonce", 1)
+	_, err = authenticator.Authenticate(context.Background(), digest)
+	assert.EqualError(t, err, "invalid credential: invalid nonce")
+}
+
+func TestDigestAuthenticator_URI(t *testing.T) {
+	authenticator := auth.NewDigestAuthenticator("my_realm", auth.DigestPasswords{"foo": "my_password"}, auth.WithDigestAlgorithms(auth.DigestSHA256))
+	_, err := authenticator.Authenticate(context.Background(), nil)
+	challenge := challengeOf(t, err, auth.DigestSHA256)
+
+	tests := []struct {
+		uri         string
+		requestURI  string
+		expectedErr string
+	}{
+		{uri: "/my_path?foo=bar", requestURI: "/my_path?foo=bar"},
+		{uri: "http://fake-addr/my_path", requestURI: "/my_path"},
+		{uri: "/my_path", requestURI: "/my_other_path", expectedErr: "invalid credential: uri mismatch"},
+		{uri: "/my_path", requestURI: "", expectedErr: "invalid credential: uri mismatch"},
+		{uri: "http://fake-addr/my_other_path", requestURI: "/my_path", expectedErr: "invalid credential: uri mismatch"},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			digest, _ := challenge.Authorize("GET", tt.uri, "foo", "my_password", "my_cnonce", uint32(i+1))
+			digest.RequestURI = tt.requestURI
+			_, err := authenticator.Authenticate(context.Background(), digest)
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestDigestAuthenticator_QuotedString(t *testing.T) {
+	realm := "my_réalm\t\"home\""
+	quotedRealm := `"my_réalm` + "\t" + `\"home\""`
+	authenticator := auth.NewDigestAuthenticator(realm, auth.DigestPasswords{"fôo": "my_password"}, auth.WithDigestAlgorithms(auth.DigestSHA256))
+	_, err := authenticator.Authenticate(context.Background(), nil)
+	challenges := auth.Challenges(err)
+	assert.Len(t, challenges, 1)
+	assert.Regexp(t, `^Digest realm=`+regexp.QuoteMeta(quotedRealm)+`, algorithm=SHA-256, nonce="[\w-]+", qop="auth"$`, challenges[0])
+
+	challenge := challengeOf(t, err, auth.DigestSHA256)
+	assert.Equal(t, realm, challenge.Realm)
+	digest, err := challenge.Authorize("GET", "/my_path", "fôo", "my_password", "my_cnonce", 1)
+	assert.NoError(t, err)
+	authorization := auth.FormatCredential(digest)
+	assert.Contains(t, authorization, `username="fôo"`)
+	assert.Contains(t, authorization, "realm="+quotedRealm)
+
+	parsed, err := auth.ParseCredential(authorization)
+	assert.NoError(t, err)
+	received := parsed.(auth.DigestCredential)
+	received.Method, received.RequestURI = "GET", "/my_path"
+	credential, err := authenticator.Authenticate(context.Background(), received)
+	assert.NoError(t, err)
+	assert.Equal(t, auth.UserPrincipal{Username: "fôo", Method: auth.AuthMethodDigest}, credential)
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrMissingCredential = errors.New("missing credential")
+	ErrInvalidCredential = errors.New("invalid credential")
+	ErrExpired           = errors.New("expired credential")
+	ErrForbidden         = errors.New("forbidden")
+	// ErrUnavailable is returned when the credential cannot be verified because the authentication backend failed
+	ErrUnavailable = errors.New("authentication unavailable")
+)
+
+// StatusCode returns the HTTP status of an authentication error: 403 for ErrForbidden, 503 for ErrUnavailable, 401 otherwise
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusUnauthorized
+}
+
+// Error is an authentication error that carries the challenge to send back in the WWW-Authenticate header
+type Error struct {
+	Err    error
+	Scheme string
+	Realm  string
+	// Description is a human readable explanation safe to send to the client (Bearer error_description)
+	Description string
+	// Params are additional challenge parameters
+	Params map[string]string
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Challenge returns the WWW-Authenticate header value
+// ex: Basic realm="my_realm"
+func (e *Error) Challenge() string {
+	parts := make([]string, 0, 2)
+	if e.Realm != "" {
+		parts = append(parts, "realm="+quoteString(e.Realm))
+	}
+	params := e.Params
+	if strings.EqualFold(e.Scheme, BearerScheme) {
+		params = e.bearerParams()
+	}
+	// algorithm and stale are tokens (RFC 7616)
+	if p := formatParams(params, "algorithm", "stale"); p != "" {
+		parts = append(parts, p)
+	}
+	if len(parts) == 0 {
+		return e.Scheme
+	}
+	return e.Scheme + " " + strings.Join(parts, ", ")
+}
+
+// bearerParams adds the error code and description to the params (RFC 6750 section 3.1)
+// a request without credential gets no error code
+func (e *Error) bearerParams() map[string]string {
+	var code string
+	switch {
+	case errors.Is(e.Err, ErrForbidden):
+		code = "insufficient_scope"
+	case errors.Is(e.Err, ErrInvalidCredential), errors.Is(e.Err, ErrExpired):
+		code = "invalid_token"
+	default:
+		return e.Params
+	}
+	params := map[string]string{"error": code}
+	if e.Description != "" {
+		params["error_description"] = e.Description
+	}
+	for key, value := range e.Params {
+		params[key] = value
+	}
+	return params
+}
+
+// Challenges returns the challenges of every Error found in the error tree
+func Challenges(err error) []string {
+	var challenges []string
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *Error:
+		return []string{e.Challenge()}
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			challenges = append(challenges, Challenges(err)...)
+		}
+	case interface{ Unwrap() error }:
+		challenges = Challenges(e.Unwrap())
+	}
+	return challenges
+}
@@ -0,0 +1,41 @@
+package auth_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		err               *auth.Error
+		expectedChallenge string
+	}{
+		{
+			err:               &auth.Error{Err: auth.ErrMissingCredential, Scheme: "Bearer"},
+			expectedChallenge: `Bearer`,
+		},
+		{
+			err:               &auth.Error{Err: auth.ErrMissingCredential, Scheme: "Basic", Realm: "my_realm"},
+			expectedChallenge: `Basic realm="my_realm"`,
+		},
+		{
+			err: &auth.Error{Err: auth.ErrInvalidCredential, Scheme: "Digest", Realm: "my_realm", Params: map[string]string{
+				"qop":   "auth",
+				"nonce": "my_nonce",
+			}},
+			expectedChallenge: `Digest realm="my_realm", nonce="my_nonce", qop="auth"`,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			assert.Equal(t, tt.expectedChallenge, tt.err.Challenge())
+			assert.Equal(t, tt.err.Err.Error(), tt.err.Error())
+			assert.True(t, errors.Is(tt.err, tt.err.Err))
+		})
+	}
+}
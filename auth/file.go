@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// watchedFile loads a file and reloads it when its modification time or size changes
+type watchedFile struct {
+	path    string
+	load    func(io.Reader) error
+	mutex   sync.Mutex
+	modTime time.Time
+	size    int64
+}
+
+// refresh reloads the file if it changed since the last load
+// when the file cannot be read or parsed the previously loaded content is kept
+func (f *watchedFile) refresh() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return nil
+	}
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := f.load(file); err != nil {
+		return err
+	}
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return nil
+}
+
+func newWatchedFile(path string, load func(io.Reader) error) (*watchedFile, error) {
+	f := &watchedFile{
+		path: path,
+		load: load,
+	}
+	return f, f.refresh()
+}
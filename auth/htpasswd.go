@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Passwords is an in-memory PasswordVerifier, it maps usernames to htpasswd password hashes
+// supported hashes are bcrypt ($2y$, $2a$, $2b$), SHA1 ({SHA}) and argon2 ($argon2id$, $argon2i$)
+type Passwords map[string]string
+
+// dummyHash is verified for the unknown usernames, the response time must not reveal whether a username exists
+const dummyHash = "$2a$10$isZu3LKvRT4Vuq1YUOgzP.OBK2N6r6g71FDzDXeWZbSbhUNjN9.Ae"
+
+func (p Passwords) Verify(username string, password string) bool {
+	hash, ok := p[username]
+	if !ok {
+		VerifyPassword(dummyHash, password)
+		return false
+	}
+	return VerifyPassword(hash, password)
+}
+
+// HtpasswdFile is a PasswordVerifier backed by an htpasswd file
+// the file is reloaded as soon as it changes
+type HtpasswdFile struct {
+	file      *watchedFile
+	mutex     sync.RWMutex
+	passwords Passwords
+}
+
+func (h *HtpasswdFile) Verify(username string, password string) bool {
+	_ = h.file.refresh()
+	h.mutex.RLock()
+	passwords := h.passwords
+	h.mutex.RUnlock()
+	return passwords.Verify(username, password)
+}
+
+func (h *HtpasswdFile) load(reader io.Reader) error {
+	passwords, err := ParseHtpasswd(reader)
+	if err != nil {
+		return err
+	}
+	h.mutex.Lock()
+	h.passwords = passwords
+	h.mutex.Unlock()
+	return nil
+}
+
+// NewHtpasswdFile loads the htpasswd file, it returns an error if the file cannot be loaded
+func NewHtpasswdFile(path string) (*HtpasswdFile, error) {
+	h := &HtpasswdFile{}
+	file, err := newWatchedFile(path, h.load)
+	if err != nil {
+		return nil, err
+	}
+	h.file = file
+	return h, nil
+}
+
+// ParseHtpasswd reads "username:hash" lines, empty lines and lines starting with # are ignored
+func ParseHtpasswd(reader io.Reader) (Passwords, error) {
+	passwords := Passwords{}
+	scanner := bufio.NewScanner(reader)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		username, hash, ok := strings.Cut(text, ":")
+		if !ok || username == "" {
+			return nil, fmt.Errorf("htpasswd: malformed line %d", line)
+		}
+		passwords[username] = hash
+	}
+	return passwords, scanner.Err()
+}
+
+// VerifyPassword compares a password with an htpasswd hash
+func VerifyPassword(hash string, password string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$2y$"), strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case strings.HasPrefix(hash, "{SHA}"):
+		sum := sha1.Sum([]byte(password))
+		expected := base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(hash[len("{SHA}"):]), []byte(expected)) == 1
+	case strings.HasPrefix(hash, "$argon2"):
+		return verifyArgon2(hash, password)
+	}
+	return false
+}
+
+// verifyArgon2 checks a PHC formatted argon2 hash: $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+func verifyArgon2(hash string, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false
+	}
+
+	var computed []byte
+	switch parts[1] {
+	case "argon2id":
+		computed = argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(expected)))
+	case "argon2i":
+		computed = argon2.Key([]byte(password), salt, time, memory, threads, uint32(len(expected)))
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare(computed, expected) == 1
+}
@@ -0,0 +1,75 @@
+package auth_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyPassword(t *testing.T) {
+	tests := []struct {
+		hash     string
+		expected bool
+	}{
+		{hash: "$2a$04$46JFldr5qAivgayL5Uk9k.RiABSBG2EPFaKORAJ1GX48AjMPWUaT.", expected: true},
+		{hash: "$2y$04$46JFldr5qAivgayL5Uk9k.RiABSBG2EPFaKORAJ1GX48AjMPWUaT.", expected: true},
+		{hash: "$2y$04$46JFldr5qAivgayL5Uk9k.RiABSBG2EPFaKORAJ1GX48AjMPWUaTT", expected: false},
+		{hash: "{SHA}XrlCgQp168hQlyqJKF1XDUhMicQ=", expected: true},
+		{hash: "{SHA}W4HGK9IHZ9OvD1xeMwwe+eD6GAY=", expected: false},
+		{hash: "$argon2id$v=19$m=1024,t=1,p=1$MDEyMzQ1Njc4OWFiY2RlZg$TdNbiUHlMc6WrTSwRJt7I1ajRZW/XNiME/NSzS0I0yk", expected: true},
+		{hash: "$argon2i$v=19$m=1024,t=1,p=1$MDEyMzQ1Njc4OWFiY2RlZg$UaLoouzMUrU/yy9z7X6RhrFaBKXKurYYGgNnfijjzo4", expected: true},
+		{hash: "$argon2i$v=19$m=1024,t=1,p=1$MDEyMzQ1Njc4OWFiY2RlZg$TdNbiUHlMc6WrTSwRJt7I1ajRZW/XNiME/NSzS0I0yk", expected: false},
+		{hash: "$argon2id$v=18$m=1024,t=1,p=1$MDEyMzQ1Njc4OWFiY2RlZg$TdNbiUHlMc6WrTSwRJt7I1ajRZW/XNiME/NSzS0I0yk", expected: false},
+		{hash: "$argon2id$malformed", expected: false},
+		{hash: "my_password", expected: false},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			assert.Equal(t, tt.expected, auth.VerifyPassword(tt.hash, "my_password"))
+		})
+	}
+}
+
+func TestParseHtpasswd(t *testing.T) {
+	passwords, err := auth.ParseHtpasswd(strings.NewReader("# comment\nfoo:{SHA}XrlCgQp168hQlyqJKF1XDUhMicQ=\n\nbar:$2y$04$46JFldr5qAivgayL5Uk9k.RiABSBG2EPFaKORAJ1GX48AjMPWUaT.\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, auth.Passwords{
+		"foo": "{SHA}XrlCgQp168hQlyqJKF1XDUhMicQ=",
+		"bar": "$2y$04$46JFldr5qAivgayL5Uk9k.RiABSBG2EPFaKORAJ1GX48AjMPWUaT.",
+	}, passwords)
+
+	_, err = auth.ParseHtpasswd(strings.NewReader("foo:{SHA}XrlCgQp168hQlyqJKF1XDUhMicQ=\nmalformed\n"))
+	assert.EqualError(t, err, "htpasswd: malformed line 2")
+}
+
+func TestHtpasswdFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "htpasswd")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ".htpasswd")
+
+	_, err = auth.NewHtpasswdFile(path)
+	assert.Error(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("foo:{SHA}XrlCgQp168hQlyqJKF1XDUhMicQ=\n"), 0600))
+	htpasswd, err := auth.NewHtpasswdFile(path)
+	assert.NoError(t, err)
+	assert.True(t, htpasswd.Verify("foo", "my_password"))
+	assert.False(t, htpasswd.Verify("foo", "wrong"))
+	assert.False(t, htpasswd.Verify("bar", "my_password"))
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("bar:$2y$04$46JFldr5qAivgayL5Uk9k.RiABSBG2EPFaKORAJ1GX48AjMPWUaT.\n"), 0600))
+	assert.False(t, htpasswd.Verify("foo", "my_password"))
+	assert.True(t, htpasswd.Verify("bar", "my_password"))
+
+	// a malformed file keeps the previous users
+	assert.NoError(t, ioutil.WriteFile(path, []byte("malformed\n"), 0600))
+	assert.True(t, htpasswd.Verify("bar", "my_password"))
+}
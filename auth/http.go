@@ -28,14 +28,22 @@ func ExtractFromHeader(request *http.Request) Credential {
 	return tokenHeader
 }
 
+// AddHeader sets string and SchemeCredential credentials in the Authorization and X-Authorization headers
 func AddHeader(request *http.Request) CredentialSetter {
 	return func(credential Credential) {
 		if request == nil {
 			return
 		}
-		if creds, ok := credential.(string); ok {
-			request.Header.Set(AuthorizationHeader, creds)
-			request.Header.Set(XAuthorizationHeader, creds)
+		var creds string
+		switch c := credential.(type) {
+		case string:
+			creds = c
+		case SchemeCredential:
+			creds = FormatCredential(c)
+		default:
+			return
 		}
+		request.Header.Set(AuthorizationHeader, creds)
+		request.Header.Set(XAuthorizationHeader, creds)
 	}
 }
@@ -55,3 +55,28 @@ func TestAddHeader(t *testing.T) {
 	credSetter("foo")
 	assert.Equal(t, "foo", req.Header.Get("Authorization"))
 }
+
+func TestAddHeader_SchemeCredential(t *testing.T) {
+	tests := []struct {
+		credential            auth.Credential
+		expectedAuthorization string
+	}{
+		{credential: nil, expectedAuthorization: ""},
+		{credential: 42, expectedAuthorization: ""},
+		{credential: auth.BearerToken("my_token"), expectedAuthorization: "Bearer my_token"},
+		{credential: auth.APIKey("my_key"), expectedAuthorization: "ApiKey my_key"},
+		{credential: auth.BasicCredential{Username: "foo", Password: "bar"}, expectedAuthorization: "Basic Zm9vOmJhcg=="},
+		{credential: auth.RawCredential{SchemeName: "Custom", Params: "my_value"}, expectedAuthorization: "Custom my_value"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			req := &http.Request{
+				Header: make(http.Header),
+			}
+			auth.AddHeader(req)(tt.credential)
+			assert.Equal(t, tt.expectedAuthorization, req.Header.Get(auth.AuthorizationHeader))
+			assert.Equal(t, tt.expectedAuthorization, req.Header.Get(auth.XAuthorizationHeader))
+		})
+	}
+}
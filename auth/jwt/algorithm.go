@@ -0,0 +1,105 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"errors"
+	"math/big"
+)
+
+const (
+	HS256 = "HS256"
+	HS384 = "HS384"
+	HS512 = "HS512"
+	RS256 = "RS256"
+	PS256 = "PS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// DefaultAlgorithms are the algorithms accepted by default
+var DefaultAlgorithms = []string{HS256, HS384, HS512, RS256, PS256, ES256, EdDSA}
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported algorithm")
+	ErrInvalidKey           = errors.New("invalid key for algorithm")
+	ErrInvalidSignature     = errors.New("invalid signature")
+)
+
+// Verify checks the signature of signingInput with the given algorithm and key
+// key must be a []byte for HMAC algorithms, an *rsa.PublicKey for RS256/PS256,
+// an *ecdsa.PublicKey for ES256 and an ed25519.PublicKey for EdDSA
+func Verify(algorithm string, key interface{}, signingInput string, signature []byte) error {
+	switch algorithm {
+	case HS256:
+		return verifyHMAC(crypto.SHA256, key, signingInput, signature)
+	case HS384:
+		return verifyHMAC(crypto.SHA384, key, signingInput, signature)
+	case HS512:
+		return verifyHMAC(crypto.SHA512, key, signingInput, signature)
+	case RS256, PS256:
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrInvalidKey
+		}
+		digest := sum(crypto.SHA256, signingInput)
+		var err error
+		if algorithm == RS256 {
+			err = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest, signature)
+		} else {
+			err = rsa.VerifyPSS(publicKey, crypto.SHA256, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+		}
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	case ES256:
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || publicKey.Curve.Params().BitSize != 256 {
+			return ErrInvalidKey
+		}
+		if len(signature) != 64 {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(publicKey, sum(crypto.SHA256, signingInput), r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case EdDSA:
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok || len(publicKey) != ed25519.PublicKeySize {
+			return ErrInvalidKey
+		}
+		if !ed25519.Verify(publicKey, []byte(signingInput), signature) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	return ErrUnsupportedAlgorithm
+}
+
+func verifyHMAC(hash crypto.Hash, key interface{}, signingInput string, signature []byte) error {
+	secret, ok := key.([]byte)
+	if !ok || len(secret) == 0 {
+		return ErrInvalidKey
+	}
+	mac := hmac.New(hash.New, secret)
+	mac.Write([]byte(signingInput))
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func sum(hash crypto.Hash, signingInput string) []byte {
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	return h.Sum(nil)
+}
@@ -0,0 +1,106 @@
+package jwt_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"hash"
+	"math/big"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	hmacKey          = []byte("my_secret")
+	rsaKey, _        = rsa.GenerateKey(rand.Reader, 2048)
+	ecdsaKey, _      = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, ed25519Key, _ = ed25519.GenerateKey(rand.Reader)
+)
+
+// sign creates a JWS compact token, key is the private key (or the secret) of the algorithm
+func sign(t *testing.T, header jwt.Header, claims map[string]interface{}, key interface{}) string {
+	headerBytes, err := json.Marshal(header)
+	assert.NoError(t, err)
+	claimsBytes, err := json.Marshal(claims)
+	assert.NoError(t, err)
+	signingInput := base64.RawURLEncoding.EncodeToString(headerBytes) + "." + base64.RawURLEncoding.EncodeToString(claimsBytes)
+
+	var signature []byte
+	hmacSign := func(h func() hash.Hash) []byte {
+		mac := hmac.New(h, key.([]byte))
+		mac.Write([]byte(signingInput))
+		return mac.Sum(nil)
+	}
+	digest := sha256.Sum256([]byte(signingInput))
+	switch header.Algorithm {
+	case jwt.HS256:
+		signature = hmacSign(sha256.New)
+	case jwt.HS384:
+		signature = hmacSign(sha512.New384)
+	case jwt.HS512:
+		signature = hmacSign(sha512.New)
+	case jwt.RS256:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	case jwt.PS256:
+		signature, err = rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case jwt.ES256:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	case jwt.EdDSA:
+		signature = ed25519.Sign(key.(ed25519.PrivateKey), []byte(signingInput))
+	}
+	assert.NoError(t, err)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		algorithm  string
+		signingKey interface{}
+		verifyKey  interface{}
+	}{
+		{algorithm: jwt.HS256, signingKey: hmacKey, verifyKey: hmacKey},
+		{algorithm: jwt.HS384, signingKey: hmacKey, verifyKey: hmacKey},
+		{algorithm: jwt.HS512, signingKey: hmacKey, verifyKey: hmacKey},
+		{algorithm: jwt.RS256, signingKey: rsaKey, verifyKey: &rsaKey.PublicKey},
+		{algorithm: jwt.PS256, signingKey: rsaKey, verifyKey: &rsaKey.PublicKey},
+		{algorithm: jwt.ES256, signingKey: ecdsaKey, verifyKey: &ecdsaKey.PublicKey},
+		{algorithm: jwt.EdDSA, signingKey: ed25519Key, verifyKey: ed25519Key.Public()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			token, err := jwt.Parse(sign(t, jwt.Header{Algorithm: tt.algorithm}, map[string]interface{}{"sub": "foo"}, tt.signingKey))
+			assert.NoError(t, err)
+
+			raw := []byte(token.Raw)
+			i := len(raw) - 1
+			for raw[i] != '.' {
+				i--
+			}
+			signature, err := base64.RawURLEncoding.DecodeString(string(raw[i+1:]))
+			assert.NoError(t, err)
+			signingInput := string(raw[:i])
+
+			assert.NoError(t, jwt.Verify(tt.algorithm, tt.verifyKey, signingInput, signature))
+			assert.Equal(t, jwt.ErrInvalidSignature, jwt.Verify(tt.algorithm, tt.verifyKey, signingInput+"x", signature))
+			// a key of another type is refused, it prevents algorithm confusion
+			assert.Equal(t, jwt.ErrInvalidKey, jwt.Verify(tt.algorithm, "my_wrong_key_type", signingInput, signature))
+		})
+	}
+
+	assert.Equal(t, jwt.ErrUnsupportedAlgorithm, jwt.Verify("none", nil, "", nil))
+}
@@ -0,0 +1,133 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+)
+
+// Authenticator validates bearer JWS tokens and returns the *Token as authenticated credential
+type Authenticator struct {
+	keys   KeySet
+	config *Config
+}
+
+func (a *Authenticator) Authenticate(ctx context.Context, credential auth.Credential) (auth.Credential, error) {
+	if raw, ok := credential.(string); ok {
+		parsed, err := auth.ParseCredential(raw)
+		if err != nil {
+			return nil, a.error(err)
+		}
+		credential = parsed
+	}
+	bearer, ok := credential.(auth.BearerToken)
+	if !ok {
+		return nil, a.error(auth.ErrMissingCredential)
+	}
+	token, err := a.Validate(ctx, string(bearer))
+	if errors.Is(err, auth.ErrExpired) {
+		return nil, a.describedError(err, "token expired")
+	}
+	if err != nil {
+		return nil, a.describedError(fmt.Errorf("%w: %s", auth.ErrInvalidCredential, err), "invalid token")
+	}
+	return token, nil
+}
+
+// Validate parses the token and checks its signature and claims
+func (a *Authenticator) Validate(ctx context.Context, raw string) (*Token, error) {
+	token, err := Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !contains(a.config.Algorithms, token.Header.Algorithm) {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if err := a.verifySignature(ctx, token); err != nil {
+		return nil, err
+	}
+	if err := a.validateClaims(token.Payload); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (a *Authenticator) verifySignature(ctx context.Context, token *Token) error {
+	keys, err := a.keys.Keys(ctx, token.Header.KeyID)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if key.Algorithm != "" && key.Algorithm != token.Header.Algorithm {
+			continue
+		}
+		if Verify(token.Header.Algorithm, key.Key, token.signingInput, token.signature) == nil {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func (a *Authenticator) validateClaims(claims Claims) error {
+	now := time.Now()
+	expiresAt, err := claims.GetTime("exp")
+	if err != nil {
+		return err
+	}
+	if !expiresAt.IsZero() && now.After(expiresAt.Add(a.config.ClockSkew)) {
+		return auth.ErrExpired
+	}
+	notBefore, err := claims.GetTime("nbf")
+	if err != nil {
+		return err
+	}
+	if !notBefore.IsZero() && now.Add(a.config.ClockSkew).Before(notBefore) {
+		return errors.New("token not valid yet")
+	}
+	issuedAt, err := claims.GetTime("iat")
+	if err != nil {
+		return err
+	}
+	if !issuedAt.IsZero() && now.Add(a.config.ClockSkew).Before(issuedAt) {
+		return errors.New("token issued in the future")
+	}
+	if len(a.config.Issuers) > 0 && !contains(a.config.Issuers, claims.Issuer()) {
+		return errors.New("invalid issuer")
+	}
+	if len(a.config.Audiences) > 0 {
+		for _, audience := range claims.Audience() {
+			if contains(a.config.Audiences, audience) {
+				return nil
+			}
+		}
+		return errors.New("invalid audience")
+	}
+	return nil
+}
+
+func (a *Authenticator) error(err error) error {
+	return a.describedError(err, "")
+}
+
+func (a *Authenticator) describedError(err error, description string) error {
+	return &auth.Error{Err: err, Scheme: auth.BearerScheme, Realm: a.config.Realm, Description: description}
+}
+
+func NewAuthenticator(keys KeySet, options ...Option) *Authenticator {
+	return &Authenticator{
+		keys:   keys,
+		config: NewConfig(options...),
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
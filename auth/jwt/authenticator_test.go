@@ -0,0 +1,123 @@
+package jwt_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/auth/jwt"
+	"github.com/gol4ng/httpware/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticator(t *testing.T) {
+	now := time.Now().Unix()
+	authenticator := jwt.NewAuthenticator(
+		jwt.StaticKeys{{ID: "hmac", Key: hmacKey}, {ID: "rsa", Algorithm: jwt.RS256, Key: &rsaKey.PublicKey}},
+		jwt.WithIssuer("my_issuer"),
+		jwt.WithAudience("my_api", "my_other_api"),
+		jwt.WithClockSkew(10*time.Second),
+		jwt.WithRealm("my_realm"),
+	)
+	validClaims := func(overrides map[string]interface{}) map[string]interface{} {
+		claims := map[string]interface{}{"iss": "my_issuer", "aud": "my_api", "sub": "foo", "exp": now + 60, "iat": now}
+		for name, value := range overrides {
+			if value == nil {
+				delete(claims, name)
+				continue
+			}
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		credential  auth.Credential
+		expectedErr string
+	}{
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256, KeyID: "hmac"}, validClaims(nil), hmacKey))},
+		{credential: "Bearer " + sign(t, jwt.Header{Algorithm: jwt.RS256, KeyID: "rsa"}, validClaims(nil), rsaKey)},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256}, validClaims(map[string]interface{}{"aud": []string{"foo", "my_other_api"}}), hmacKey))},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256}, validClaims(map[string]interface{}{"exp": now - 5, "nbf": now + 5, "iat": now + 5}), hmacKey))},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256}, validClaims(map[string]interface{}{"exp": nil}), hmacKey))},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256}, validClaims(map[string]interface{}{"exp": 32503680000}), hmacKey))},
+		{credential: nil, expectedErr: "missing credential"},
+		{credential: "Basic Zm9vOmJhcg==", expectedErr: "missing credential"},
+		{credential: auth.BearerToken("malformed"), expectedErr: "invalid credential: malformed token"},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: "none"}, validClaims(nil), nil)), expectedErr: "invalid credential: unsupported algorithm"},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256, KeyID: "hmac"}, validClaims(nil), []byte("wrong"))), expectedErr: "invalid credential: invalid signature"},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256, KeyID: "rsa"}, validClaims(nil), hmacKey)), expectedErr: "invalid credential: invalid signature"},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256}, validClaims(map[string]interface{}{"exp": now - 20}), hmacKey)), expectedErr: "expired credential"},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256}, validClaims(map[string]interface{}{"nbf": now + 20}), hmacKey)), expectedErr: "invalid credential: token not valid yet"},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256}, validClaims(map[string]interface{}{"iat": now + 20}), hmacKey)), expectedErr: "invalid credential: token issued in the future"},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256}, validClaims(map[string]interface{}{"exp": "x"}), hmacKey)), expectedErr: `invalid credential: malformed "exp" claim`},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256}, validClaims(map[string]interface{}{"nbf": true}), hmacKey)), expectedErr: `invalid credential: malformed "nbf" claim`},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256}, validClaims(map[string]interface{}{"iss": "other"}), hmacKey)), expectedErr: "invalid credential: invalid issuer"},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256}, validClaims(map[string]interface{}{"aud": nil}), hmacKey)), expectedErr: "invalid credential: invalid audience"},
+		{credential: auth.BearerToken(sign(t, jwt.Header{Algorithm: jwt.HS256}, validClaims(map[string]interface{}{"aud": []string{"other"}}), hmacKey)), expectedErr: "invalid credential: invalid audience"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			credential, err := authenticator.Authenticate(context.Background(), tt.credential)
+			if tt.expectedErr != "" {
+				assert.Nil(t, credential)
+				assert.EqualError(t, err, tt.expectedErr)
+				authErr := &auth.Error{}
+				assert.True(t, errors.As(err, &authErr))
+				switch {
+				case errors.Is(err, auth.ErrMissingCredential):
+					assert.Equal(t, `Bearer realm="my_realm"`, authErr.Challenge())
+				case errors.Is(err, auth.ErrExpired):
+					assert.Equal(t, `Bearer realm="my_realm", error="invalid_token", error_description="token expired"`, authErr.Challenge())
+				default:
+					assert.Equal(t, `Bearer realm="my_realm", error="invalid_token", error_description="invalid token"`, authErr.Challenge())
+				}
+				return
+			}
+			assert.NoError(t, err)
+			token, ok := credential.(*jwt.Token)
+			assert.True(t, ok)
+			assert.Equal(t, "foo", token.Payload.Subject())
+			assert.Equal(t, "my_issuer", token.Payload.Issuer())
+			principal := auth.PrincipalFromContext(auth.CredentialToContext(context.Background(), credential))
+			assert.Equal(t, "foo", principal.Subject())
+			assert.Equal(t, "my_issuer", principal.Issuer())
+			assert.Equal(t, auth.AuthMethodJWT, principal.AuthMethod())
+		})
+	}
+}
+
+func TestAuthenticator_RemoteJWKS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(jwks(t, rsaJWK("rsa", &rsaKey.PublicKey))))
+	}))
+	defer server.Close()
+
+	authenticator := jwt.NewAuthenticator(jwt.NewRemoteJWKS(server.URL))
+	handler := middleware.Authentication(
+		middleware.NewAuthenticateFunc(authenticator),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		token := auth.CredentialFromContext(request.Context()).(*jwt.Token)
+		_, _ = writer.Write([]byte(token.Payload.Subject()))
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	request.Header.Set(auth.AuthorizationHeader, "Bearer "+sign(t, jwt.Header{Algorithm: jwt.RS256, KeyID: "rsa"}, map[string]interface{}{"sub": "foo"}, rsaKey))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "foo", recorder.Body.String())
+
+	request = httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	request.Header.Set(auth.AuthorizationHeader, "Bearer "+sign(t, jwt.Header{Algorithm: jwt.ES256, KeyID: "rsa"}, map[string]interface{}{"sub": "foo"}, ecdsaKey))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, `Bearer error="invalid_token", error_description="invalid token"`, recorder.Header().Get("WWW-Authenticate"))
+}
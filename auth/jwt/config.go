@@ -0,0 +1,72 @@
+package jwt
+
+import (
+	"time"
+)
+
+type Config struct {
+	// Algorithms accepted in the token header
+	Algorithms []string
+	// Issuers accepted in the "iss" claim, empty accepts any issuer
+	Issuers []string
+	// Audiences accepted in the "aud" claim, empty accepts any audience
+	Audiences []string
+	// ClockSkew tolerated when checking the "exp", "nbf" and "iat" claims
+	ClockSkew time.Duration
+	// Realm sent back in the WWW-Authenticate challenge
+	Realm string
+}
+
+func (c *Config) apply(options ...Option) *Config {
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// NewConfig returns a new jwt configuration with all options applied
+func NewConfig(options ...Option) *Config {
+	config := &Config{
+		Algorithms: DefaultAlgorithms,
+		ClockSkew:  time.Minute,
+	}
+	return config.apply(options...)
+}
+
+// Option defines a jwt Authenticator configuration option
+type Option func(*Config)
+
+// WithAlgorithms will configure Algorithms option
+func WithAlgorithms(algorithms ...string) Option {
+	return func(config *Config) {
+		config.Algorithms = algorithms
+	}
+}
+
+// WithIssuer will configure Issuers option
+func WithIssuer(issuers ...string) Option {
+	return func(config *Config) {
+		config.Issuers = issuers
+	}
+}
+
+// WithAudience will configure Audiences option
+func WithAudience(audiences ...string) Option {
+	return func(config *Config) {
+		config.Audiences = audiences
+	}
+}
+
+// WithClockSkew will configure ClockSkew option
+func WithClockSkew(clockSkew time.Duration) Option {
+	return func(config *Config) {
+		config.ClockSkew = clockSkew
+	}
+}
+
+// WithRealm will configure Realm option
+func WithRealm(realm string) Option {
+	return func(config *Config) {
+		config.Realm = realm
+	}
+}
@@ -0,0 +1,148 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+)
+
+// Key is a verification key
+type Key struct {
+	// ID is matched against the token "kid" header, an empty ID matches any token
+	ID string
+	// Algorithm restricts the key usage to a given algorithm, empty allows any algorithm compatible with the key
+	Algorithm string
+	// Key is a []byte secret, an *rsa.PublicKey, an *ecdsa.PublicKey or an ed25519.PublicKey
+	Key interface{}
+}
+
+// jsonWebKey is the RFC 7517 representation of a key
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+	K         string `json:"k"`
+}
+
+// errUnsupportedKey is returned for the key types and curves that cannot be used to verify a token
+var errUnsupportedKey = errors.New("unsupported key")
+
+// ParseJWKS parses a JSON Web Key Set
+// keys that are not signature keys or that have an unsupported type, curve or algorithm are ignored
+func ParseJWKS(reader io.Reader) ([]Key, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	set := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("malformed jwks: %w", err)
+	}
+
+	keys := make([]Key, 0, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if (jwk.Use != "" && jwk.Use != "sig") || (jwk.Algorithm != "" && !supportedAlgorithm(jwk.Algorithm)) {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jwk %q: %w", jwk.KeyID, err)
+		}
+		keys = append(keys, Key{ID: jwk.KeyID, Algorithm: jwk.Algorithm, Key: key})
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.KeyType {
+	case "oct":
+		return decode(k.K)
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("%w: curve %q", errUnsupportedKey, k.Curve)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		publicKey := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, errors.New("invalid ec point")
+		}
+		return publicKey, nil
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("%w: curve %q", errUnsupportedKey, k.Curve)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("%w: type %q", errUnsupportedKey, k.KeyType)
+}
+
+func supportedAlgorithm(algorithm string) bool {
+	for _, supported := range DefaultAlgorithms {
+		if algorithm == supported {
+			return true
+		}
+	}
+	return false
+}
+
+func decode(value string) ([]byte, error) {
+	if value == "" {
+		return nil, errors.New("missing key parameter")
+	}
+	return base64.RawURLEncoding.DecodeString(value)
+}
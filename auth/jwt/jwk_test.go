@@ -0,0 +1,65 @@
+package jwt_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func jwks(t *testing.T, keys ...map[string]string) string {
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{"kty": "RSA", "kid": kid, "alg": jwt.RS256, "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func TestParseJWKS(t *testing.T) {
+	keys, err := jwt.ParseJWKS(strings.NewReader(jwks(t,
+		rsaJWK("rsa", &rsaKey.PublicKey),
+		map[string]string{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecdsaKey.X.Bytes()), "y": b64(ecdsaKey.Y.Bytes())},
+		map[string]string{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(ed25519Key.Public().(ed25519.PublicKey))},
+		map[string]string{"kty": "oct", "kid": "hmac", "k": b64(hmacKey)},
+		map[string]string{"kty": "RSA", "kid": "encryption", "use": "enc", "n": b64(rsaKey.N.Bytes()), "e": "AQAB"},
+		// unsupported keys are skipped
+		map[string]string{"kty": "bar", "kid": "type"},
+		map[string]string{"kty": "EC", "kid": "curve", "crv": "P-1"},
+		map[string]string{"kty": "OKP", "kid": "x25519", "crv": "X25519", "x": "AQ"},
+		map[string]string{"kty": "RSA", "kid": "algorithm", "alg": "RS512", "n": b64(rsaKey.N.Bytes()), "e": "AQAB"},
+	)))
+	assert.NoError(t, err)
+	assert.Equal(t, []jwt.Key{
+		{ID: "rsa", Algorithm: jwt.RS256, Key: &rsaKey.PublicKey},
+		{ID: "ec", Key: &ecdsaKey.PublicKey},
+		{ID: "ed", Key: ed25519Key.Public()},
+		{ID: "hmac", Key: hmacKey},
+	}, keys)
+}
+
+func TestParseJWKS_Error(t *testing.T) {
+	tests := map[string]string{
+		"malformed jwks: unexpected end of JSON input": `{"keys":`,
+		`jwk "foo": invalid ec point`:                  jwks(t, map[string]string{"kty": "EC", "kid": "foo", "crv": "P-256", "x": "AQ", "y": "AQ"}),
+		`jwk "foo": missing key parameter`:             jwks(t, map[string]string{"kty": "RSA", "kid": "foo"}),
+		`jwk "foo": invalid ed25519 key size`:          jwks(t, map[string]string{"kty": "OKP", "kid": "foo", "crv": "Ed25519", "x": "AQ"}),
+	}
+	for expectedErr, set := range tests {
+		t.Run(expectedErr, func(t *testing.T) {
+			_, err := jwt.ParseJWKS(strings.NewReader(set))
+			assert.EqualError(t, err, expectedErr)
+		})
+	}
+}
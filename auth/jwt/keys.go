@@ -0,0 +1,185 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// KeySet provides the keys used to verify the token signatures
+type KeySet interface {
+	// Keys returns the keys matching the token "kid" header (it can be empty)
+	Keys(ctx context.Context, keyID string) ([]Key, error)
+}
+
+// StaticKeys is a KeySet of keys given by configuration
+type StaticKeys []Key
+
+func (s StaticKeys) Keys(_ context.Context, keyID string) ([]Key, error) {
+	return filterKeys(s, keyID), nil
+}
+
+// LoadJWKSFile loads a local JSON Web Key Set file
+func LoadJWKSFile(path string) (StaticKeys, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseJWKS(file)
+}
+
+// RemoteJWKS is a KeySet that fetches a JSON Web Key Set url
+// keys are cached for the configured TTL, an unknown key id triggers a refresh in order to follow key rotations
+// the concurrent refreshes are merged and a refresh is attempted at most once every minRefreshInterval, even when it failed
+type RemoteJWKS struct {
+	url                string
+	client             *http.Client
+	ttl                time.Duration
+	minRefreshInterval time.Duration
+	fetchTimeout       time.Duration
+
+	group       singleflight.Group
+	mutex       sync.Mutex
+	keys        []Key
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	err         error
+}
+
+func (r *RemoteJWKS) Keys(ctx context.Context, keyID string) ([]Key, error) {
+	r.mutex.Lock()
+	keys, fetchedAt := r.keys, r.fetchedAt
+	r.mutex.Unlock()
+
+	if keys == nil || time.Since(fetchedAt) >= r.ttl {
+		refreshed, err := r.refresh(ctx)
+		if err != nil && refreshed == nil {
+			return nil, err
+		}
+		keys = refreshed
+	}
+	matches := filterKeys(keys, keyID)
+	if len(matches) == 0 && keyID != "" {
+		refreshed, err := r.refresh(ctx)
+		if err != nil {
+			return nil, err
+		}
+		matches = filterKeys(refreshed, keyID)
+	}
+	return matches, nil
+}
+
+// refresh fetches the key set outside of the lock, it returns the current keys and the last error
+// when the previous attempt is more recent than minRefreshInterval
+// the fetch is shared by the concurrent callers and bounded by the fetch timeout instead of the caller context
+func (r *RemoteJWKS) refresh(ctx context.Context) ([]Key, error) {
+	results := r.group.DoChan(r.url, func() (interface{}, error) {
+		r.mutex.Lock()
+		if !r.attemptedAt.IsZero() && time.Since(r.attemptedAt) < r.minRefreshInterval {
+			defer r.mutex.Unlock()
+			return r.keys, r.err
+		}
+		r.attemptedAt = time.Now()
+		r.mutex.Unlock()
+
+		// the shared fetch must not depend on the context of the first caller
+		fetchCtx, cancel := context.WithTimeout(context.Background(), r.fetchTimeout)
+		defer cancel()
+		keys, err := r.fetch(fetchCtx)
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		r.err = err
+		if err != nil {
+			return r.keys, err
+		}
+		r.keys = keys
+		r.fetchedAt = time.Now()
+		return keys, nil
+	})
+	select {
+	case result := <-results:
+		keys, _ := result.Val.([]Key)
+		return keys, result.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (r *RemoteJWKS) fetch(ctx context.Context) ([]Key, error) {
+	request, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := r.client.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: unexpected status %d", response.StatusCode)
+	}
+	return ParseJWKS(response.Body)
+}
+
+// RemoteJWKSOption defines a RemoteJWKS configuration option
+type RemoteJWKSOption func(*RemoteJWKS)
+
+// NewRemoteJWKS creates a KeySet that fetches the given url
+// by default keys are cached 1 hour, a rotation refresh can happen every 5 minutes and a fetch times out after 10 seconds
+func NewRemoteJWKS(url string, options ...RemoteJWKSOption) *RemoteJWKS {
+	r := &RemoteJWKS{
+		url:                url,
+		client:             http.DefaultClient,
+		ttl:                time.Hour,
+		minRefreshInterval: 5 * time.Minute,
+		fetchTimeout:       10 * time.Second,
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// WithHTTPClient will configure the client used to fetch the key set
+func WithHTTPClient(client *http.Client) RemoteJWKSOption {
+	return func(r *RemoteJWKS) {
+		r.client = client
+	}
+}
+
+// WithCacheTTL will configure how long the keys are cached
+func WithCacheTTL(ttl time.Duration) RemoteJWKSOption {
+	return func(r *RemoteJWKS) {
+		r.ttl = ttl
+	}
+}
+
+// WithMinRefreshInterval will configure the minimum delay between two refreshes triggered by an unknown key id
+func WithMinRefreshInterval(interval time.Duration) RemoteJWKSOption {
+	return func(r *RemoteJWKS) {
+		r.minRefreshInterval = interval
+	}
+}
+
+// WithFetchTimeout will configure the timeout of the key set fetch
+func WithFetchTimeout(timeout time.Duration) RemoteJWKSOption {
+	return func(r *RemoteJWKS) {
+		r.fetchTimeout = timeout
+	}
+}
+
+func filterKeys(keys []Key, keyID string) []Key {
+	matches := make([]Key, 0, len(keys))
+	for _, key := range keys {
+		if keyID == "" || key.ID == "" || key.ID == keyID {
+			matches = append(matches, key)
+		}
+	}
+	return matches
+}
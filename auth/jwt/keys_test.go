@@ -0,0 +1,179 @@
+package jwt_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticKeys(t *testing.T) {
+	keys := jwt.StaticKeys{
+		{ID: "foo", Key: hmacKey},
+		{ID: "bar", Key: hmacKey},
+		{Key: hmacKey},
+	}
+
+	matches, err := keys.Keys(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, []jwt.Key{{ID: "foo", Key: hmacKey}, {Key: hmacKey}}, matches)
+
+	matches, err = keys.Keys(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Len(t, matches, 3)
+}
+
+func TestLoadJWKSFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwks")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "jwks.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(jwks(t, rsaJWK("rsa", &rsaKey.PublicKey))), 0600))
+
+	keys, err := jwt.LoadJWKSFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, jwt.StaticKeys{{ID: "rsa", Algorithm: jwt.RS256, Key: &rsaKey.PublicKey}}, keys)
+
+	_, err = jwt.LoadJWKSFile(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestRemoteJWKS(t *testing.T) {
+	var calls int32
+	kid := "key_1"
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = writer.Write([]byte(jwks(t, rsaJWK(kid, &rsaKey.PublicKey))))
+	}))
+	defer server.Close()
+
+	remote := jwt.NewRemoteJWKS(server.URL, jwt.WithCacheTTL(time.Hour), jwt.WithMinRefreshInterval(0))
+
+	keys, err := remote.Keys(context.Background(), "key_1")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	keys, err = remote.Keys(context.Background(), "key_1")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// the key was rotated, an unknown kid triggers a refresh
+	kid = "key_2"
+	keys, err = remote.Keys(context.Background(), "key_2")
+	assert.NoError(t, err)
+	assert.Equal(t, []jwt.Key{{ID: "key_2", Algorithm: jwt.RS256, Key: &rsaKey.PublicKey}}, keys)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	keys, err = remote.Keys(context.Background(), "key_1")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestRemoteJWKS_MinRefreshInterval(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = writer.Write([]byte(jwks(t, rsaJWK("key_1", &rsaKey.PublicKey))))
+	}))
+	defer server.Close()
+
+	remote := jwt.NewRemoteJWKS(server.URL, jwt.WithHTTPClient(server.Client()))
+	for i := 0; i < 3; i++ {
+		keys, err := remote.Keys(context.Background(), "unknown")
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRemoteJWKS_Error(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		writer.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	remote := jwt.NewRemoteJWKS(server.URL)
+	for i := 0; i < 3; i++ {
+		_, err := remote.Keys(context.Background(), "")
+		assert.EqualError(t, err, "jwks: unexpected status 500")
+	}
+	// the failed attempt is not retried before the min refresh interval
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRemoteJWKS_Concurrent(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		_, _ = writer.Write([]byte(jwks(t, rsaJWK("key_1", &rsaKey.PublicKey))))
+	}))
+	defer server.Close()
+
+	remote := jwt.NewRemoteJWKS(server.URL)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys, err := remote.Keys(context.Background(), "key_1")
+			assert.NoError(t, err)
+			assert.Len(t, keys, 1)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRemoteJWKS_CanceledCaller(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		_, _ = writer.Write([]byte(jwks(t, rsaJWK("key_1", &rsaKey.PublicKey))))
+	}))
+	defer server.Close()
+
+	remote := jwt.NewRemoteJWKS(server.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		keys, err := remote.Keys(context.Background(), "key_1")
+		assert.NoError(t, err)
+		assert.Len(t, keys, 1)
+	}()
+	time.AfterFunc(5*time.Millisecond, cancel)
+	// the cancellation of a caller does not fail the shared fetch
+	_, err := remote.Keys(ctx, "key_1")
+	assert.Equal(t, context.Canceled, err)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRemoteJWKS_FetchTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	remote := jwt.NewRemoteJWKS(server.URL, jwt.WithFetchTimeout(10*time.Millisecond))
+	_, err := remote.Keys(context.Background(), "key_1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
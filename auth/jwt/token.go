@@ -0,0 +1,198 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+)
+
+// Header is the JOSE header of a token
+type Header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// Token is a parsed JWS compact token
+type Token struct {
+	Raw     string
+	Header  Header
+	Payload Claims
+	// signed part of the token: base64(header).base64(payload)
+	signingInput string
+	signature    []byte
+}
+
+// Scopes returns the token "scope" or "scp" claim
+func (t *Token) Scopes() []string {
+	return t.Payload.Scopes()
+}
+
+// Roles returns the token "roles" claim
+func (t *Token) Roles() []string {
+	return t.Payload.Roles()
+}
+
+// ExpiresAt returns the token "exp" claim, zero when the token never expires
+func (t *Token) ExpiresAt() time.Time {
+	exp, _ := t.Payload.GetTime("exp")
+	return exp
+}
+
+// Subject returns the token "sub" claim
+func (t *Token) Subject() string {
+	return t.Payload.Subject()
+}
+
+// Issuer returns the token "iss" claim
+func (t *Token) Issuer() string {
+	return t.Payload.Issuer()
+}
+
+// Claims returns a copy of the token claims set
+func (t *Token) Claims() map[string]interface{} {
+	claims := make(map[string]interface{}, len(t.Payload))
+	for name, value := range t.Payload {
+		claims[name] = value
+	}
+	return claims
+}
+
+func (t *Token) AuthMethod() string {
+	return auth.AuthMethodJWT
+}
+
+// OriginalCredential returns the bearer token sent by the client (see auth.CredentialKey)
+func (t *Token) OriginalCredential() auth.Credential {
+	if t.Raw == "" {
+		return nil
+	}
+	return auth.BearerToken(t.Raw)
+}
+
+// Claims is the token claims set
+type Claims map[string]interface{}
+
+func (c Claims) GetString(name string) string {
+	value, _ := c[name].(string)
+	return value
+}
+
+// GetStrings returns a claim that can be a string or a string array
+func (c Claims) GetStrings(name string) []string {
+	switch value := c[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// GetTime returns a NumericDate claim, the time is zero when the claim is missing
+// an error is returned when the claim is not a number or is out of the time range
+func (c Claims) GetTime(name string) (time.Time, error) {
+	var seconds float64
+	switch value := c[name].(type) {
+	case nil:
+		return time.Time{}, nil
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return time.Unix(i, 0), nil
+		}
+		f, err := value.Float64()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("malformed %q claim", name)
+		}
+		seconds = f
+	case float64:
+		seconds = value
+	case int64:
+		return time.Unix(value, 0), nil
+	case int:
+		return time.Unix(int64(value), 0), nil
+	default:
+		return time.Time{}, fmt.Errorf("malformed %q claim", name)
+	}
+	if math.IsNaN(seconds) || seconds >= math.MaxInt64 || seconds <= math.MinInt64 {
+		return time.Time{}, fmt.Errorf("malformed %q claim", name)
+	}
+	sec, frac := math.Modf(seconds)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+}
+
+func (c Claims) Issuer() string {
+	return c.GetString("iss")
+}
+
+func (c Claims) Subject() string {
+	return c.GetString("sub")
+}
+
+func (c Claims) Audience() []string {
+	return c.GetStrings("aud")
+}
+
+func (c Claims) ID() string {
+	return c.GetString("jti")
+}
+
+// Scopes returns the space separated "scope" claim (RFC 8693) or the "scp" claim used by some providers
+func (c Claims) Scopes() []string {
+	if scope, ok := c["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	return c.GetStrings("scp")
+}
+
+func (c Claims) Roles() []string {
+	return c.GetStrings("roles")
+}
+
+// Parse decodes a JWS compact token without verifying it
+func Parse(raw string) (*Token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("malformed token header")
+	}
+	token := &Token{
+		Raw:          raw,
+		signingInput: parts[0] + "." + parts[1],
+	}
+	if err := json.Unmarshal(headerBytes, &token.Header); err != nil {
+		return nil, errors.New("malformed token header")
+	}
+
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed token payload")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payloadBytes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&token.Payload); err != nil {
+		return nil, errors.New("malformed token payload")
+	}
+
+	if token.signature, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+	return token, nil
+}
@@ -0,0 +1,119 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/auth/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	raw := sign(t, jwt.Header{Algorithm: jwt.HS256, KeyID: "my_kid", Type: "JWT"}, map[string]interface{}{
+		"iss": "my_issuer",
+		"sub": "foo",
+		"aud": []string{"my_api", "my_other_api"},
+		"jti": "my_id",
+		"exp": 1700000000,
+		"nbf": 1600000000.5,
+	}, hmacKey)
+
+	token, err := jwt.Parse(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, raw, token.Raw)
+	assert.Equal(t, jwt.Header{Algorithm: jwt.HS256, KeyID: "my_kid", Type: "JWT"}, token.Header)
+	assert.Equal(t, "my_issuer", token.Payload.Issuer())
+	assert.Equal(t, "foo", token.Payload.Subject())
+	assert.Equal(t, []string{"my_api", "my_other_api"}, token.Payload.Audience())
+	assert.Equal(t, "my_id", token.Payload.ID())
+
+	expiresAt, err := token.Payload.GetTime("exp")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Unix(1700000000, 0), expiresAt)
+	notBefore, err := token.Payload.GetTime("nbf")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Unix(1600000000, 500000000), notBefore)
+	issuedAt, err := token.Payload.GetTime("iat")
+	assert.NoError(t, err)
+	assert.True(t, issuedAt.IsZero())
+}
+
+func TestClaims_GetTime(t *testing.T) {
+	tests := []struct {
+		value        interface{}
+		expectedTime time.Time
+		expectedErr  string
+	}{
+		{value: nil},
+		{value: json.Number("1700000000"), expectedTime: time.Unix(1700000000, 0)},
+		{value: json.Number("1600000000.5"), expectedTime: time.Unix(1600000000, 500000000)},
+		// after 2262 the time cannot be represented in nanoseconds
+		{value: json.Number("32503680000"), expectedTime: time.Unix(32503680000, 0)},
+		{value: json.Number("32503680000.25"), expectedTime: time.Unix(32503680000, 250000000)},
+		{value: float64(1700000000), expectedTime: time.Unix(1700000000, 0)},
+		{value: 1700000000, expectedTime: time.Unix(1700000000, 0)},
+		{value: json.Number("1e30"), expectedErr: `malformed "exp" claim`},
+		{value: "x", expectedErr: `malformed "exp" claim`},
+		{value: true, expectedErr: `malformed "exp" claim`},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			claims := jwt.Claims{}
+			if tt.value != nil {
+				claims["exp"] = tt.value
+			}
+			expiresAt, err := claims.GetTime("exp")
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tt.expectedTime.Equal(expiresAt))
+		})
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	tests := map[string]string{
+		"a.b":               "malformed token",
+		"!.e30.":            "malformed token header",
+		"e30.!.":            "malformed token payload",
+		"e30.W10.":          "malformed token payload",
+		"e30.e30.!":         "malformed token signature",
+		"bm90IGpzb24.e30.a": "malformed token header",
+	}
+	for raw, expectedErr := range tests {
+		t.Run(fmt.Sprint(raw), func(t *testing.T) {
+			_, err := jwt.Parse(raw)
+			assert.EqualError(t, err, expectedErr)
+		})
+	}
+}
+
+func TestToken_Claims(t *testing.T) {
+	token := &jwt.Token{Payload: jwt.Claims{"sub": "foo"}}
+	claims := token.Claims()
+	assert.Equal(t, map[string]interface{}{"sub": "foo"}, claims)
+	claims["sub"] = "bar"
+	assert.Equal(t, "foo", token.Subject())
+}
+
+func TestToken_OriginalCredential(t *testing.T) {
+	key, ok := auth.CredentialKey(&jwt.Token{Raw: "my_token"})
+	assert.True(t, ok)
+	bearerKey, _ := auth.CredentialKey("Bearer my_token")
+	assert.Equal(t, bearerKey, key)
+
+	_, ok = auth.CredentialKey(&jwt.Token{})
+	assert.False(t, ok)
+}
+
+func TestClaims_Scopes(t *testing.T) {
+	assert.Equal(t, []string{"read", "write"}, jwt.Claims{"scope": "read write"}.Scopes())
+	assert.Equal(t, []string{"read", "write"}, jwt.Claims{"scp": []interface{}{"read", "write"}}.Scopes())
+	assert.Nil(t, jwt.Claims{}.Scopes())
+	assert.Equal(t, []string{"admin"}, jwt.Claims{"roles": []interface{}{"admin"}}.Roles())
+}
@@ -0,0 +1,69 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ClientAuth authenticates the client with HTTP Basic (RFC 6749 section 2.3.1)
+type ClientAuth struct {
+	ClientID     string
+	ClientSecret string
+}
+
+func (c ClientAuth) apply(request *http.Request) {
+	if c.ClientID == "" {
+		return
+	}
+	request.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+}
+
+// PostForm sends the form to an authorization server endpoint and decodes the JSON response in result
+// a non 2xx response is returned as *Error
+func PostForm(ctx context.Context, client *http.Client, endpoint string, clientAuth ClientAuth, form url.Values, result interface{}) error {
+	request, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	clientAuth.apply(request)
+
+	response, err := client.Do(request.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		oauthErr := &Error{}
+		_ = json.Unmarshal(body, oauthErr)
+		oauthErr.StatusCode = response.StatusCode
+		return oauthErr
+	}
+	return json.Unmarshal(body, result)
+}
+
+// RequestToken calls the token endpoint with the given grant form
+func RequestToken(ctx context.Context, client *http.Client, tokenURL string, clientAuth ClientAuth, form url.Values) (*Token, error) {
+	token := &Token{}
+	if err := PostForm(ctx, client, tokenURL, clientAuth, form, token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, &Error{Code: "invalid_response", Description: "missing access_token", StatusCode: http.StatusOK}
+	}
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
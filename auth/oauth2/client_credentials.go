@@ -0,0 +1,69 @@
+package oauth2
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ClientCredentials is a TokenSource using the client credentials grant (RFC 6749 section 4.4)
+// the token is cached until shortly before it expires, concurrent refreshes share the same token request
+type ClientCredentials struct {
+	tokenURL   string
+	clientAuth ClientAuth
+	scopes     []string
+	config     *Config
+
+	group singleflight.Group
+	mutex sync.RWMutex
+	token *Token
+}
+
+func (c *ClientCredentials) Token(ctx context.Context) (*Token, error) {
+	c.mutex.RLock()
+	token := c.token
+	c.mutex.RUnlock()
+	if token.Valid(c.config.ExpiryDelta) {
+		return token, nil
+	}
+
+	result, err, _ := c.group.Do("token", func() (interface{}, error) {
+		form := url.Values{"grant_type": {"client_credentials"}}
+		if len(c.scopes) > 0 {
+			form.Set("scope", strings.Join(c.scopes, " "))
+		}
+		token, err := RequestToken(ctx, c.config.HTTPClient, c.tokenURL, c.clientAuth, form)
+		if err != nil {
+			return nil, err
+		}
+		c.mutex.Lock()
+		c.token = token
+		c.mutex.Unlock()
+		return token, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*Token), nil
+}
+
+func (c *ClientCredentials) Invalidate(token *Token) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.token == token {
+		c.token = nil
+	}
+}
+
+// NewClientCredentials returns a TokenSource requesting tokens with the client credentials grant
+func NewClientCredentials(tokenURL string, clientID string, clientSecret string, scopes []string, options ...Option) *ClientCredentials {
+	return &ClientCredentials{
+		tokenURL:   tokenURL,
+		clientAuth: ClientAuth{ClientID: clientID, ClientSecret: clientSecret},
+		scopes:     scopes,
+		config:     NewConfig(options...),
+	}
+}
@@ -0,0 +1,105 @@
+package oauth2_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth/oauth2"
+	"github.com/stretchr/testify/assert"
+)
+
+func tokenServer(t *testing.T, calls *int32, expiresIn int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+		clientID, clientSecret, ok := request.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "my_client", clientID)
+		assert.Equal(t, "my_secret", clientSecret)
+		assert.Equal(t, "client_credentials", request.PostFormValue("grant_type"))
+		assert.Equal(t, "read write", request.PostFormValue("scope"))
+		// slow down the token endpoint to have concurrent requests waiting
+		time.Sleep(10 * time.Millisecond)
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(writer, `{"access_token":"token_%d","token_type":"Bearer","expires_in":%d}`, n, expiresIn)
+	}))
+}
+
+func TestClientCredentials_Token(t *testing.T) {
+	var calls int32
+	server := tokenServer(t, &calls, 3600)
+	defer server.Close()
+
+	source := oauth2.NewClientCredentials(server.URL, "my_client", "my_secret", []string{"read", "write"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := source.Token(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "token_1", token.AccessToken)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+
+	token, err := source.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token_1", token.AccessToken)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), token.Expiry, time.Second)
+
+	source.Invalidate(&oauth2.Token{AccessToken: "token_1"})
+	token, err = source.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token_1", token.AccessToken)
+
+	source.Invalidate(token)
+	token, err = source.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token_2", token.AccessToken)
+	assert.Equal(t, int32(2), calls)
+}
+
+func TestClientCredentials_Token_ExpiryDelta(t *testing.T) {
+	var calls int32
+	server := tokenServer(t, &calls, 5)
+	defer server.Close()
+
+	source := oauth2.NewClientCredentials(server.URL, "my_client", "my_secret", []string{"read", "write"})
+
+	// the token expires in less than the default 10s expiry delta, it is fetched each time
+	token, err := source.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token_1", token.AccessToken)
+	token, err = source.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token_2", token.AccessToken)
+
+	source = oauth2.NewClientCredentials(server.URL, "my_client", "my_secret", []string{"read", "write"}, oauth2.WithExpiryDelta(time.Second))
+	token, err = source.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token_3", token.AccessToken)
+	token, err = source.Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token_3", token.AccessToken)
+}
+
+func TestClientCredentials_Token_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusUnauthorized)
+		_, _ = writer.Write([]byte(`{"error":"invalid_client","error_description":"unknown client"}`))
+	}))
+	defer server.Close()
+
+	token, err := oauth2.NewClientCredentials(server.URL, "my_client", "my_secret", nil).Token(context.Background())
+	assert.Nil(t, token)
+	assert.Equal(t, &oauth2.Error{Code: "invalid_client", Description: "unknown client", StatusCode: http.StatusUnauthorized}, err)
+	assert.EqualError(t, err, "oauth2: invalid_client: unknown client")
+}
@@ -0,0 +1,75 @@
+package oauth2
+
+import (
+	"net/http"
+	"time"
+)
+
+type Config struct {
+	// HTTPClient used to call the authorization server, it can be decorated with tripperwares
+	HTTPClient *http.Client
+	// ExpiryDelta is subtracted from the token expiry so the token is refreshed shortly before it expires
+	ExpiryDelta time.Duration
+	// Realm sent back in the WWW-Authenticate challenge by the introspection authenticator
+	Realm string
+	// CacheSize is the number of exchanged tokens kept by the TokenExchange
+	CacheSize int
+	// ExchangeTTL is the cache lifetime of the exchanged tokens that have no expiry, nor a subject token expiry
+	ExchangeTTL time.Duration
+}
+
+func (c *Config) apply(options ...Option) *Config {
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// NewConfig returns a new oauth2 configuration with all options applied
+func NewConfig(options ...Option) *Config {
+	config := &Config{
+		HTTPClient:  http.DefaultClient,
+		ExpiryDelta: 10 * time.Second,
+		CacheSize:   1000,
+		ExchangeTTL: 5 * time.Minute,
+	}
+	return config.apply(options...)
+}
+
+// Option defines an oauth2 configuration option
+type Option func(*Config)
+
+// WithHTTPClient will configure HTTPClient option
+func WithHTTPClient(client *http.Client) Option {
+	return func(config *Config) {
+		config.HTTPClient = client
+	}
+}
+
+// WithExpiryDelta will configure ExpiryDelta option
+func WithExpiryDelta(expiryDelta time.Duration) Option {
+	return func(config *Config) {
+		config.ExpiryDelta = expiryDelta
+	}
+}
+
+// WithRealm will configure Realm option
+func WithRealm(realm string) Option {
+	return func(config *Config) {
+		config.Realm = realm
+	}
+}
+
+// WithCacheSize will configure CacheSize option
+func WithCacheSize(cacheSize int) Option {
+	return func(config *Config) {
+		config.CacheSize = cacheSize
+	}
+}
+
+// WithExchangeTTL will configure ExchangeTTL option
+func WithExchangeTTL(ttl time.Duration) Option {
+	return func(config *Config) {
+		config.ExchangeTTL = ttl
+	}
+}
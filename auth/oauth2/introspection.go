@@ -0,0 +1,166 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+)
+
+// Introspection is a token introspection response (RFC 7662 section 2.2)
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	// Exp, Iat and Nbf are NumericDate, they can be fractional
+	Exp json.Number `json:"exp,omitempty"`
+	Iat json.Number `json:"iat,omitempty"`
+	Nbf json.Number `json:"nbf,omitempty"`
+	Sub string      `json:"sub,omitempty"`
+	Iss string      `json:"iss,omitempty"`
+	Jti string      `json:"jti,omitempty"`
+	// Raw holds the whole response, including the audience and the extension claims
+	Raw map[string]interface{} `json:"-"`
+	// Token is the introspected token, it can be exchanged for a downstream token (see TokenExchange)
+	Token string `json:"-"`
+}
+
+// Scopes returns the token scopes
+func (i *Introspection) Scopes() []string {
+	return strings.Fields(i.Scope)
+}
+
+// ExpiresAt returns the token expiration time, zero when the server did not send it
+func (i *Introspection) ExpiresAt() time.Time {
+	return numericDate(i.Exp)
+}
+
+// Subject returns the "sub" member, or the username when the token has no subject
+func (i *Introspection) Subject() string {
+	if i.Sub != "" {
+		return i.Sub
+	}
+	return i.Username
+}
+
+func (i *Introspection) Issuer() string {
+	return i.Iss
+}
+
+// Claims returns a copy of all the members of the introspection response
+func (i *Introspection) Claims() map[string]interface{} {
+	claims := make(map[string]interface{}, len(i.Raw))
+	for name, value := range i.Raw {
+		claims[name] = value
+	}
+	return claims
+}
+
+func (i *Introspection) AuthMethod() string {
+	return auth.AuthMethodIntrospection
+}
+
+// OriginalCredential returns the introspected bearer token (see auth.CredentialKey)
+func (i *Introspection) OriginalCredential() auth.Credential {
+	if i.Token == "" {
+		return nil
+	}
+	return auth.BearerToken(i.Token)
+}
+
+func (i *Introspection) UnmarshalJSON(data []byte) error {
+	type introspection Introspection
+	if err := json.Unmarshal(data, (*introspection)(i)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &i.Raw)
+}
+
+// IntrospectionAuthenticator validates opaque bearer tokens with an introspection endpoint (RFC 7662)
+// it returns the *Introspection as authenticated credential, wrap it with auth.Cached to avoid a call on each request
+type IntrospectionAuthenticator struct {
+	endpoint   string
+	clientAuth ClientAuth
+	config     *Config
+}
+
+func (a *IntrospectionAuthenticator) Authenticate(ctx context.Context, credential auth.Credential) (auth.Credential, error) {
+	if raw, ok := credential.(string); ok {
+		parsed, err := auth.ParseCredential(raw)
+		if err != nil {
+			return nil, a.error(err)
+		}
+		credential = parsed
+	}
+	bearer, ok := credential.(auth.BearerToken)
+	if !ok {
+		return nil, a.error(auth.ErrMissingCredential)
+	}
+
+	introspection, err := a.Introspect(ctx, string(bearer))
+	if err != nil {
+		// the token could not be verified, it is not an invalid credential
+		return nil, fmt.Errorf("%w: %w", auth.ErrUnavailable, err)
+	}
+	if !introspection.Active {
+		return nil, a.describedError(fmt.Errorf("%w: inactive token", auth.ErrInvalidCredential), "inactive token")
+	}
+	if exp := introspection.ExpiresAt(); !exp.IsZero() && time.Now().After(exp) {
+		return nil, a.describedError(auth.ErrExpired, "token expired")
+	}
+	return introspection, nil
+}
+
+// Introspect calls the introspection endpoint
+func (a *IntrospectionAuthenticator) Introspect(ctx context.Context, token string) (*Introspection, error) {
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {"access_token"},
+	}
+	introspection := &Introspection{}
+	if err := PostForm(ctx, a.config.HTTPClient, a.endpoint, a.clientAuth, form, introspection); err != nil {
+		return nil, fmt.Errorf("introspection: %w", err)
+	}
+	introspection.Token = token
+	return introspection, nil
+}
+
+// numericDate returns the time of a NumericDate, zero when it is missing or malformed
+func numericDate(number json.Number) time.Time {
+	if seconds, err := number.Int64(); err == nil {
+		if seconds == 0 {
+			return time.Time{}
+		}
+		return time.Unix(seconds, 0)
+	}
+	seconds, err := number.Float64()
+	if err != nil || math.IsNaN(seconds) || seconds >= math.MaxInt64 || seconds <= math.MinInt64 {
+		return time.Time{}
+	}
+	sec, frac := math.Modf(seconds)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second)))
+}
+
+func (a *IntrospectionAuthenticator) error(err error) error {
+	return a.describedError(err, "")
+}
+
+func (a *IntrospectionAuthenticator) describedError(err error, description string) error {
+	return &auth.Error{Err: err, Scheme: auth.BearerScheme, Realm: a.config.Realm, Description: description}
+}
+
+// NewIntrospectionAuthenticator creates an IntrospectionAuthenticator, the client credentials authenticate it to the endpoint
+func NewIntrospectionAuthenticator(endpoint string, clientID string, clientSecret string, options ...Option) *IntrospectionAuthenticator {
+	return &IntrospectionAuthenticator{
+		endpoint:   endpoint,
+		clientAuth: ClientAuth{ClientID: clientID, ClientSecret: clientSecret},
+		config:     NewConfig(options...),
+	}
+}
@@ -0,0 +1,130 @@
+package oauth2_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/auth/oauth2"
+	"github.com/stretchr/testify/assert"
+)
+
+func introspectionServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		clientID, clientSecret, _ := request.BasicAuth()
+		if clientID != "my_client" || clientSecret != "my_secret" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			_, _ = writer.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		assert.Equal(t, "access_token", request.PostFormValue("token_type_hint"))
+		switch request.PostFormValue("token") {
+		case "my_token":
+			_, _ = fmt.Fprintf(writer, `{"active":true,"scope":"read write","client_id":"my_app","sub":"foo","exp":%d,"aud":["my_api"]}`, time.Now().Add(time.Hour).Unix())
+		case "my_expired_token":
+			_, _ = fmt.Fprintf(writer, `{"active":true,"exp":%d}`, time.Now().Add(-time.Hour).Unix())
+		default:
+			_, _ = writer.Write([]byte(`{"active":false}`))
+		}
+	}))
+}
+
+func TestIntrospectionAuthenticator(t *testing.T) {
+	server := introspectionServer(t)
+	defer server.Close()
+	authenticator := oauth2.NewIntrospectionAuthenticator(server.URL, "my_client", "my_secret", oauth2.WithRealm("my_realm"))
+
+	credential, err := authenticator.Authenticate(context.Background(), "Bearer my_token")
+	assert.NoError(t, err)
+	introspection := credential.(*oauth2.Introspection)
+	assert.True(t, introspection.Active)
+	assert.Equal(t, []string{"read", "write"}, introspection.Scopes())
+	assert.Equal(t, "my_app", introspection.ClientID)
+	assert.Equal(t, "foo", introspection.Sub)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), introspection.ExpiresAt(), 2*time.Second)
+	assert.Equal(t, []interface{}{"my_api"}, introspection.Raw["aud"])
+	assert.Equal(t, "foo", introspection.Subject())
+	assert.Equal(t, "my_token", introspection.Token)
+	assert.Equal(t, auth.AuthMethodIntrospection, introspection.AuthMethod())
+
+	tests := []struct {
+		credential        auth.Credential
+		expectedError     string
+		expectedChallenge string
+	}{
+		{credential: nil, expectedError: "missing credential", expectedChallenge: `Bearer realm="my_realm"`},
+		{credential: auth.APIKey("my_key"), expectedError: "missing credential", expectedChallenge: `Bearer realm="my_realm"`},
+		{credential: auth.BearerToken("my_inactive_token"), expectedError: "invalid credential: inactive token", expectedChallenge: `Bearer realm="my_realm", error="invalid_token", error_description="inactive token"`},
+		{credential: auth.BearerToken("my_expired_token"), expectedError: "expired credential", expectedChallenge: `Bearer realm="my_realm", error="invalid_token", error_description="token expired"`},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			credential, err := authenticator.Authenticate(context.Background(), tt.credential)
+			assert.Nil(t, credential)
+			assert.EqualError(t, err, tt.expectedError)
+			assert.Equal(t, tt.expectedChallenge, err.(*auth.Error).Challenge())
+		})
+	}
+}
+
+func TestIntrospectionAuthenticator_ClientError(t *testing.T) {
+	server := introspectionServer(t)
+	defer server.Close()
+
+	credential, err := oauth2.NewIntrospectionAuthenticator(server.URL, "my_client", "wrong_secret").Authenticate(context.Background(), auth.BearerToken("my_token"))
+	assert.Nil(t, credential)
+	assert.EqualError(t, err, "authentication unavailable: introspection: oauth2: invalid_client")
+	assert.True(t, errors.Is(err, auth.ErrUnavailable))
+	assert.Equal(t, http.StatusServiceUnavailable, auth.StatusCode(err))
+}
+
+func TestIntrospectionAuthenticator_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusInternalServerError)
+	}))
+	unavailable := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	unavailable.Close()
+	defer server.Close()
+
+	for i, endpoint := range []string{server.URL, unavailable.URL} {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			credential, err := oauth2.NewIntrospectionAuthenticator(endpoint, "my_client", "my_secret").Authenticate(context.Background(), auth.BearerToken("my_token"))
+			assert.Nil(t, credential)
+			assert.True(t, errors.Is(err, auth.ErrUnavailable))
+			assert.False(t, errors.Is(err, auth.ErrInvalidCredential))
+			assert.Equal(t, http.StatusServiceUnavailable, auth.StatusCode(err))
+			assert.Empty(t, auth.Challenges(err))
+		})
+	}
+}
+
+func TestIntrospection_FractionalExp(t *testing.T) {
+	introspection := &oauth2.Introspection{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"active":true,"exp":1700000000.5,"iat":1600000000.25}`), introspection))
+	assert.Equal(t, time.Unix(1700000000, 500000000), introspection.ExpiresAt())
+	assert.True(t, (&oauth2.Introspection{}).ExpiresAt().IsZero())
+}
+
+func TestIntrospection_Claims(t *testing.T) {
+	introspection := &oauth2.Introspection{Raw: map[string]interface{}{"sub": "foo"}}
+	claims := introspection.Claims()
+	assert.Equal(t, map[string]interface{}{"sub": "foo"}, claims)
+	claims["sub"] = "bar"
+	assert.Equal(t, "foo", introspection.Raw["sub"])
+}
+
+func TestIntrospection_OriginalCredential(t *testing.T) {
+	key, ok := auth.CredentialKey(&oauth2.Introspection{Active: true, Token: "my_token"})
+	assert.True(t, ok)
+	bearerKey, _ := auth.CredentialKey("Bearer my_token")
+	assert.Equal(t, bearerKey, key)
+
+	_, ok = auth.CredentialKey(&oauth2.Introspection{Active: true})
+	assert.False(t, ok)
+}
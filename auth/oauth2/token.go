@@ -0,0 +1,53 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Token is an OAuth2 token endpoint response (RFC 6749 section 5.1)
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	// IssuedTokenType is only sent by token exchange endpoints (RFC 8693)
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+	// Expiry is computed from ExpiresIn when the token is received, zero when the token never expires
+	Expiry time.Time `json:"-"`
+}
+
+// Valid reports whether the token can be used for at least the given delta
+func (t *Token) Valid(delta time.Duration) bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Now().Add(delta).Before(t.Expiry)
+}
+
+// TokenSource provides the tokens to send to the resource servers
+type TokenSource interface {
+	// Token returns a valid token, it can be cached
+	Token(ctx context.Context) (*Token, error)
+	// Invalidate drops the given token when it is cached, the next Token call will fetch a new one
+	Invalidate(token *Token)
+}
+
+// Error is an OAuth2 error response (RFC 6749 section 5.2)
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	StatusCode  int    `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("oauth2: unexpected status %d", e.StatusCode)
+	}
+	if e.Description == "" {
+		return "oauth2: " + e.Code
+	}
+	return "oauth2: " + e.Code + ": " + e.Description
+}
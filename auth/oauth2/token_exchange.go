@@ -0,0 +1,148 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// token exchange identifiers (RFC 8693 section 3)
+const (
+	TokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	AccessTokenType        = "urn:ietf:params:oauth:token-type:access_token"
+	JWTTokenType           = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// SubjectToken is the token exchanged on behalf of its owner
+type SubjectToken struct {
+	Token string
+	// Type is the RFC 8693 token type (ie: AccessTokenType, JWTTokenType)
+	Type string
+	// Expiry of the subject token, the exchanged token is not cached after it, zero when it is unknown
+	Expiry time.Time
+}
+
+// TokenExchange exchanges the tokens of the incoming requests for tokens of a downstream audience (RFC 8693)
+// the exchanged tokens are cached per subject token and audience until shortly before they expire,
+// the subject token expires or ExchangeTTL elapsed when none of them has an expiry
+type TokenExchange struct {
+	tokenURL   string
+	clientAuth ClientAuth
+	config     *Config
+
+	group  singleflight.Group
+	mutex  sync.Mutex
+	tokens map[string]*exchangedToken
+}
+
+type exchangedToken struct {
+	token *Token
+	// expiry is the cache expiration of the token
+	expiry time.Time
+}
+
+func (e *exchangedToken) valid(delta time.Duration) bool {
+	return e != nil && time.Now().Add(delta).Before(e.expiry)
+}
+
+// Exchange returns a token of the audience on behalf of the subject token
+func (t *TokenExchange) Exchange(ctx context.Context, subject SubjectToken, audience string, scopes []string) (*Token, error) {
+	key := exchangeKey(subject.Token, audience, scopes)
+	t.mutex.Lock()
+	cached := t.tokens[key]
+	t.mutex.Unlock()
+	if cached.valid(t.config.ExpiryDelta) {
+		return cached.token, nil
+	}
+
+	result, err, _ := t.group.Do(key, func() (interface{}, error) {
+		form := url.Values{
+			"grant_type":           {TokenExchangeGrantType},
+			"subject_token":        {subject.Token},
+			"subject_token_type":   {subject.Type},
+			"requested_token_type": {AccessTokenType},
+		}
+		if audience != "" {
+			form.Set("audience", audience)
+		}
+		if len(scopes) > 0 {
+			form.Set("scope", strings.Join(scopes, " "))
+		}
+		token, err := RequestToken(ctx, t.config.HTTPClient, t.tokenURL, t.clientAuth, form)
+		if err != nil {
+			return nil, err
+		}
+		t.store(key, &exchangedToken{token: token, expiry: t.cacheExpiry(token, subject)})
+		return token, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*Token), nil
+}
+
+// Invalidate drops the exchanged token from the cache
+func (t *TokenExchange) Invalidate(subject SubjectToken, audience string, scopes []string, token *Token) {
+	key := exchangeKey(subject.Token, audience, scopes)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if cached, ok := t.tokens[key]; ok && cached.token == token {
+		delete(t.tokens, key)
+	}
+}
+
+// cacheExpiry returns the token expiry capped by the subject token expiry, or the ExchangeTTL when both are unknown
+func (t *TokenExchange) cacheExpiry(token *Token, subject SubjectToken) time.Time {
+	expiry := token.Expiry
+	if !subject.Expiry.IsZero() && (expiry.IsZero() || subject.Expiry.Before(expiry)) {
+		expiry = subject.Expiry
+	}
+	if expiry.IsZero() {
+		expiry = time.Now().Add(t.config.ExchangeTTL)
+	}
+	return expiry
+}
+
+func (t *TokenExchange) store(key string, token *exchangedToken) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, ok := t.tokens[key]; !ok && len(t.tokens) >= t.config.CacheSize {
+		for k, cached := range t.tokens {
+			if !cached.valid(t.config.ExpiryDelta) {
+				delete(t.tokens, k)
+			}
+		}
+		// evict an arbitrary entry when every token is still valid
+		for k := range t.tokens {
+			if len(t.tokens) < t.config.CacheSize {
+				break
+			}
+			delete(t.tokens, k)
+		}
+	}
+	if t.config.CacheSize > 0 {
+		t.tokens[key] = token
+	}
+}
+
+// NewTokenExchange returns a TokenExchange calling the token endpoint of the security token service
+func NewTokenExchange(tokenURL string, clientID string, clientSecret string, options ...Option) *TokenExchange {
+	return &TokenExchange{
+		tokenURL:   tokenURL,
+		clientAuth: ClientAuth{ClientID: clientID, ClientSecret: clientSecret},
+		config:     NewConfig(options...),
+		tokens:     map[string]*exchangedToken{},
+	}
+}
+
+// exchangeKey hashes the subject token, the cache must not keep the incoming tokens in clear
+func exchangeKey(subjectToken string, audience string, scopes []string) string {
+	sum := sha256.Sum256([]byte(subjectToken))
+	return hex.EncodeToString(sum[:]) + " " + audience + " " + strings.Join(scopes, " ")
+}
@@ -0,0 +1,128 @@
+package oauth2_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth/oauth2"
+	"github.com/stretchr/testify/assert"
+)
+
+// stsServer is a fake security token service issuing "<audience>:<subject_token>:<n>" tokens
+func stsServer(t *testing.T, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+		clientID, clientSecret, _ := request.BasicAuth()
+		assert.Equal(t, "my_client", clientID)
+		assert.Equal(t, "my_secret", clientSecret)
+		assert.Equal(t, oauth2.TokenExchangeGrantType, request.PostFormValue("grant_type"))
+		assert.Equal(t, oauth2.AccessTokenType, request.PostFormValue("requested_token_type"))
+		if request.PostFormValue("subject_token") == "revoked" {
+			writer.WriteHeader(http.StatusBadRequest)
+			_, _ = writer.Write([]byte(`{"error":"invalid_grant","error_description":"subject token revoked"}`))
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(writer, `{"access_token":"%s:%s:%d","issued_token_type":"%s","token_type":"Bearer","expires_in":3600}`,
+			request.PostFormValue("audience"), request.PostFormValue("subject_token"), n, oauth2.AccessTokenType)
+	}))
+}
+
+func TestTokenExchange_Exchange(t *testing.T) {
+	var calls int32
+	server := stsServer(t, &calls)
+	defer server.Close()
+
+	exchange := oauth2.NewTokenExchange(server.URL, "my_client", "my_secret")
+	tests := []struct {
+		subjectToken  string
+		audience      string
+		expectedToken string
+		expectedCalls int32
+	}{
+		{subjectToken: "foo", audience: "my_api", expectedToken: "my_api:foo:1", expectedCalls: 1},
+		{subjectToken: "foo", audience: "my_api", expectedToken: "my_api:foo:1", expectedCalls: 1},
+		{subjectToken: "foo", audience: "my_other_api", expectedToken: "my_other_api:foo:2", expectedCalls: 2},
+		{subjectToken: "bar", audience: "my_api", expectedToken: "my_api:bar:3", expectedCalls: 3},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			token, err := exchange.Exchange(context.Background(), oauth2.SubjectToken{Token: tt.subjectToken, Type: oauth2.AccessTokenType}, tt.audience, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedToken, token.AccessToken)
+			assert.Equal(t, oauth2.AccessTokenType, token.IssuedTokenType)
+			assert.Equal(t, tt.expectedCalls, atomic.LoadInt32(&calls))
+		})
+	}
+
+	foo := oauth2.SubjectToken{Token: "foo", Type: oauth2.AccessTokenType}
+	token, _ := exchange.Exchange(context.Background(), foo, "my_api", nil)
+	exchange.Invalidate(foo, "my_api", nil, token)
+	token, err := exchange.Exchange(context.Background(), foo, "my_api", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "my_api:foo:4", token.AccessToken)
+
+	_, err = exchange.Exchange(context.Background(), oauth2.SubjectToken{Token: "revoked", Type: oauth2.AccessTokenType}, "my_api", nil)
+	oauthErr := &oauth2.Error{}
+	assert.True(t, errors.As(err, &oauthErr))
+	assert.Equal(t, "invalid_grant", oauthErr.Code)
+}
+
+func TestTokenExchange_CacheSize(t *testing.T) {
+	var calls int32
+	server := stsServer(t, &calls)
+	defer server.Close()
+
+	exchange := oauth2.NewTokenExchange(server.URL, "my_client", "my_secret", oauth2.WithCacheSize(1))
+	for _, subjectToken := range []string{"foo", "bar", "bar", "foo"} {
+		_, err := exchange.Exchange(context.Background(), oauth2.SubjectToken{Token: subjectToken, Type: oauth2.AccessTokenType}, "my_api", nil)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(3), calls)
+}
+
+func TestTokenExchange_CacheExpiry(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		// the exchanged token has no expiry
+		_, _ = writer.Write([]byte(`{"access_token":"my_token","token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		exchangeTTL time.Duration
+		// subjectTTL is the subject token lifetime, zero when its expiry is unknown
+		subjectTTL time.Duration
+	}{
+		// the ExchangeTTL is used when the subject token expiry is unknown
+		{exchangeTTL: 50 * time.Millisecond},
+		// the subject token expiry caps the cache lifetime
+		{exchangeTTL: time.Hour, subjectTTL: 50 * time.Millisecond},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			atomic.StoreInt32(&calls, 0)
+			exchange := oauth2.NewTokenExchange(server.URL, "my_client", "my_secret", oauth2.WithExpiryDelta(0), oauth2.WithExchangeTTL(tt.exchangeTTL))
+			subject := oauth2.SubjectToken{Token: "foo", Type: oauth2.AccessTokenType}
+			if tt.subjectTTL > 0 {
+				subject.Expiry = time.Now().Add(tt.subjectTTL)
+			}
+			for j := 0; j < 2; j++ {
+				_, err := exchange.Exchange(context.Background(), subject, "my_api", nil)
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+			time.Sleep(60 * time.Millisecond)
+			_, err := exchange.Exchange(context.Background(), subject, "my_api", nil)
+			assert.NoError(t, err)
+			assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		})
+	}
+}
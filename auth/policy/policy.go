@@ -0,0 +1,133 @@
+package policy
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gol4ng/httpware/v4/auth"
+)
+
+// Policy decides if the authenticated credential can access the request
+// it returns an error wrapping auth.ErrForbidden when the access is denied,
+// the denials of this package are *auth.Error, the Authorize middleware sends them back with its configured challenge scheme
+type Policy func(req *http.Request, credential auth.Credential) error
+
+// ScopeProvider is implemented by the credentials that carry OAuth2 like scopes
+type ScopeProvider interface {
+	Scopes() []string
+}
+
+// RoleProvider is implemented by the credentials that carry roles
+type RoleProvider interface {
+	Roles() []string
+}
+
+// Authenticated allows every authenticated credential
+func Authenticated() Policy {
+	return func(_ *http.Request, _ auth.Credential) error {
+		return nil
+	}
+}
+
+// RequireScopes allows the credentials having all the given scopes
+func RequireScopes(scopes ...string) Policy {
+	return func(_ *http.Request, credential auth.Credential) error {
+		provider, ok := credential.(ScopeProvider)
+		if !ok {
+			return denied(fmt.Errorf("%w: credential has no scope", auth.ErrForbidden), scopes)
+		}
+		if missing := missing(scopes, provider.Scopes()); missing != "" {
+			return denied(fmt.Errorf("%w: missing scope %q", auth.ErrForbidden, missing), scopes)
+		}
+		return nil
+	}
+}
+
+// RequireRoles allows the credentials having all the given roles
+func RequireRoles(roles ...string) Policy {
+	return func(_ *http.Request, credential auth.Credential) error {
+		provider, ok := credential.(RoleProvider)
+		if !ok {
+			return denied(fmt.Errorf("%w: credential has no role", auth.ErrForbidden), nil)
+		}
+		if missing := missing(roles, provider.Roles()); missing != "" {
+			return denied(fmt.Errorf("%w: missing role %q", auth.ErrForbidden, missing), nil)
+		}
+		return nil
+	}
+}
+
+// RequireAuthMethods allows the principals authenticated with one of the given methods (see auth.Principal)
+func RequireAuthMethods(methods ...string) Policy {
+	return func(_ *http.Request, credential auth.Credential) error {
+		principal, ok := credential.(auth.Principal)
+		if !ok {
+			return denied(fmt.Errorf("%w: credential is not a principal", auth.ErrForbidden), nil)
+		}
+		for _, method := range methods {
+			if principal.AuthMethod() == method {
+				return nil
+			}
+		}
+		return denied(fmt.Errorf("%w: auth method %q not allowed", auth.ErrForbidden, principal.AuthMethod()), nil)
+	}
+}
+
+// AllOf allows the access when all policies allow it, the first denial is returned
+func AllOf(policies ...Policy) Policy {
+	return func(req *http.Request, credential auth.Credential) error {
+		for _, policy := range policies {
+			if err := policy(req, credential); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// AnyOf allows the access when one of the policies allows it, otherwise the first denial is returned
+func AnyOf(policies ...Policy) Policy {
+	return func(req *http.Request, credential auth.Credential) error {
+		var firstErr error
+		for _, policy := range policies {
+			err := policy(req, credential)
+			if err == nil {
+				return nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if firstErr == nil {
+			return denied(fmt.Errorf("%w: no policy", auth.ErrForbidden), nil)
+		}
+		return firstErr
+	}
+}
+
+// denied returns the denial as a Bearer challenge (the default Authorize scheme), the required scopes are sent in the "scope" parameter
+func denied(err error, scopes []string) error {
+	var params map[string]string
+	if len(scopes) > 0 {
+		params = map[string]string{"scope": strings.Join(scopes, " ")}
+	}
+	return &auth.Error{Err: err, Scheme: auth.BearerScheme, Params: params}
+}
+
+// missing returns the first required value not found in values
+func missing(required []string, values []string) string {
+	for _, r := range required {
+		found := false
+		for _, v := range values {
+			if v == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return r
+		}
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package policy_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/auth/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+type credential struct {
+	scopes []string
+	roles  []string
+}
+
+func (c credential) Scopes() []string {
+	return c.scopes
+}
+
+func (c credential) Roles() []string {
+	return c.roles
+}
+
+func TestPolicies(t *testing.T) {
+	reader := credential{scopes: []string{"read"}, roles: []string{"user"}}
+	admin := credential{scopes: []string{"read", "write"}, roles: []string{"user", "admin"}}
+	isGet := policy.Policy(func(req *http.Request, _ auth.Credential) error {
+		if req.Method != http.MethodGet {
+			return fmt.Errorf("%w: read only", auth.ErrForbidden)
+		}
+		return nil
+	})
+
+	tests := []struct {
+		policy        policy.Policy
+		credential    auth.Credential
+		method        string
+		expectedError string
+	}{
+		{policy: policy.Authenticated(), credential: "my_credential"},
+		{policy: policy.RequireScopes("read"), credential: reader},
+		{policy: policy.RequireScopes("read", "write"), credential: reader, expectedError: `forbidden: missing scope "write"`},
+		{policy: policy.RequireScopes("read", "write"), credential: admin},
+		{policy: policy.RequireScopes("read"), credential: "my_credential", expectedError: "forbidden: credential has no scope"},
+		{policy: policy.RequireScopes("read"), credential: &auth.APIKeyEntry{Scope: "read write"}},
+		{policy: policy.RequireRoles("admin"), credential: reader, expectedError: `forbidden: missing role "admin"`},
+		{policy: policy.RequireRoles("admin"), credential: admin},
+		{policy: policy.RequireRoles("admin"), credential: "my_credential", expectedError: "forbidden: credential has no role"},
+		{policy: policy.RequireAuthMethods(auth.AuthMethodBasic, auth.AuthMethodAPIKey), credential: &auth.APIKeyEntry{}},
+		{policy: policy.RequireAuthMethods(auth.AuthMethodBasic), credential: &auth.APIKeyEntry{}, expectedError: `forbidden: auth method "api_key" not allowed`},
+		{policy: policy.RequireAuthMethods(auth.AuthMethodBasic), credential: "my_credential", expectedError: "forbidden: credential is not a principal"},
+		{policy: policy.AllOf(policy.RequireScopes("read"), isGet), credential: reader},
+		{policy: policy.AllOf(policy.RequireScopes("read"), isGet), credential: reader, method: http.MethodPost, expectedError: "forbidden: read only"},
+		{policy: policy.AnyOf(policy.RequireRoles("admin"), isGet), credential: reader},
+		{policy: policy.AnyOf(policy.RequireRoles("admin"), isGet), credential: admin, method: http.MethodPost},
+		{policy: policy.AnyOf(policy.RequireRoles("admin"), isGet), credential: reader, method: http.MethodPost, expectedError: `forbidden: missing role "admin"`},
+		{policy: policy.AnyOf(), credential: reader, expectedError: "forbidden: no policy"},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			err := tt.policy(httptest.NewRequest(method, "http://fake-addr", nil), tt.credential)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expectedError)
+			assert.ErrorIs(t, err, auth.ErrForbidden)
+		})
+	}
+}
+
+func TestPolicies_Challenge(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	tests := []struct {
+		policy            policy.Policy
+		expectedChallenge string
+	}{
+		{policy: policy.RequireScopes("read", "write"), expectedChallenge: `Bearer error="insufficient_scope", scope="read write"`},
+		{policy: policy.RequireRoles("admin"), expectedChallenge: `Bearer error="insufficient_scope"`},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			assert.Equal(t, []string{tt.expectedChallenge}, auth.Challenges(tt.policy(request, credential{})))
+		})
+	}
+}
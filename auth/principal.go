@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// authentication methods reported by the bundled principals
+const (
+	AuthMethodBasic             = "basic"
+	AuthMethodDigest            = "digest"
+	AuthMethodAPIKey            = "api_key"
+	AuthMethodClientCertificate = "client_certificate"
+	AuthMethodClientIdentity    = "client_identity"
+	AuthMethodJWT               = "jwt"
+	AuthMethodIntrospection     = "oauth2_introspection"
+	AuthMethodSession           = "session"
+)
+
+// Principal is the authenticated identity returned by the bundled authenticators
+// it gives a stable shape to the credential stored in the context (see PrincipalFromContext)
+type Principal interface {
+	// Subject identifies the authenticated user or client
+	Subject() string
+	// Issuer is the authority that issued the credential, empty when it is the application itself
+	Issuer() string
+	Scopes() []string
+	// Claims are the extra attributes of the principal, the returned map is a copy
+	Claims() map[string]interface{}
+	// ExpiresAt returns the credential expiration time, zero when it never expires
+	ExpiresAt() time.Time
+	// AuthMethod is the authentication method (see AuthMethodBasic, AuthMethodJWT...)
+	AuthMethod() string
+}
+
+// PrincipalFromContext returns the context credential (see CredentialToContext) when it is a Principal, nil otherwise
+func PrincipalFromContext(ctx context.Context) Principal {
+	principal, _ := CredentialFromContext(ctx).(Principal)
+	return principal
+}
+
+// UserPrincipal is the Principal of the username/password authenticators
+type UserPrincipal struct {
+	Username string
+	Method   string
+}
+
+func (u UserPrincipal) Subject() string {
+	return u.Username
+}
+
+func (u UserPrincipal) Issuer() string {
+	return ""
+}
+
+func (u UserPrincipal) Scopes() []string {
+	return nil
+}
+
+func (u UserPrincipal) Claims() map[string]interface{} {
+	return nil
+}
+
+func (u UserPrincipal) ExpiresAt() time.Time {
+	return time.Time{}
+}
+
+func (u UserPrincipal) AuthMethod() string {
+	return u.Method
+}
@@ -0,0 +1,80 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipals(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+	certificate := &x509.Certificate{
+		Issuer:   pkix.Name{CommonName: "my_ca"},
+		NotAfter: expiry,
+	}
+
+	tests := []struct {
+		principal          auth.Principal
+		expectedSubject    string
+		expectedIssuer     string
+		expectedScopes     []string
+		expectedClaims     map[string]interface{}
+		expectedExpiresAt  time.Time
+		expectedAuthMethod string
+	}{
+		{
+			principal:          auth.UserPrincipal{Username: "foo", Method: auth.AuthMethodBasic},
+			expectedSubject:    "foo",
+			expectedAuthMethod: auth.AuthMethodBasic,
+		},
+		{
+			principal:          &auth.APIKeyEntry{Owner: "foo", Scope: "read write", Expiry: expiry, Metadata: map[string]string{"team": "bar"}},
+			expectedSubject:    "foo",
+			expectedScopes:     []string{"read", "write"},
+			expectedClaims:     map[string]interface{}{"team": "bar"},
+			expectedExpiresAt:  expiry,
+			expectedAuthMethod: auth.AuthMethodAPIKey,
+		},
+		{
+			principal:          &auth.ClientIdentity{CommonName: "foo", URIs: []string{"spiffe://my_domain/foo"}, SPIFFEID: "spiffe://my_domain/foo"},
+			expectedSubject:    "spiffe://my_domain/foo",
+			expectedClaims:     map[string]interface{}{"cn": "foo", "dns": []string(nil), "uri": []string{"spiffe://my_domain/foo"}},
+			expectedAuthMethod: auth.AuthMethodClientIdentity,
+		},
+		{
+			principal:          &auth.ClientCertificate{ClientIdentity: auth.ClientIdentity{CommonName: "foo"}, Chain: []*x509.Certificate{certificate}},
+			expectedSubject:    "foo",
+			expectedIssuer:     "CN=my_ca",
+			expectedClaims:     map[string]interface{}{"cn": "foo", "dns": []string(nil), "uri": []string(nil)},
+			expectedExpiresAt:  expiry,
+			expectedAuthMethod: auth.AuthMethodClientCertificate,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			assert.Equal(t, tt.expectedSubject, tt.principal.Subject())
+			assert.Equal(t, tt.expectedIssuer, tt.principal.Issuer())
+			assert.Equal(t, tt.expectedScopes, tt.principal.Scopes())
+			if tt.expectedClaims != nil {
+				assert.Equal(t, tt.expectedClaims, tt.principal.Claims())
+			}
+			assert.Equal(t, tt.expectedExpiresAt, tt.principal.ExpiresAt())
+			assert.Equal(t, tt.expectedAuthMethod, tt.principal.AuthMethod())
+		})
+	}
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	assert.Nil(t, auth.PrincipalFromContext(context.Background()))
+	assert.Nil(t, auth.PrincipalFromContext(auth.CredentialToContext(context.Background(), "foo")))
+
+	principal := auth.UserPrincipal{Username: "foo", Method: auth.AuthMethodDigest}
+	assert.Equal(t, principal, auth.PrincipalFromContext(auth.CredentialToContext(context.Background(), principal)))
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	BearerScheme = "Bearer"
+	APIKeyScheme = "ApiKey"
+)
+
+// SchemeCredential is a credential that can be serialized into an Authorization header: "<Scheme> <Parameters>"
+type SchemeCredential interface {
+	Scheme() string
+	Parameters() string
+}
+
+// BearerToken is a "Bearer <token>" credential
+type BearerToken string
+
+func (t BearerToken) Scheme() string {
+	return BearerScheme
+}
+
+func (t BearerToken) Parameters() string {
+	return string(t)
+}
+
+// APIKey is an "ApiKey <key>" credential
+type APIKey string
+
+func (k APIKey) Scheme() string {
+	return APIKeyScheme
+}
+
+func (k APIKey) Parameters() string {
+	return string(k)
+}
+
+// BasicCredential is a "Basic <base64(username:password)>" credential
+type BasicCredential struct {
+	Username string
+	Password string
+}
+
+func (c BasicCredential) Scheme() string {
+	return BasicScheme
+}
+
+func (c BasicCredential) Parameters() string {
+	return base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password))
+}
+
+// RawCredential is the credential of a scheme without registered parser
+type RawCredential struct {
+	SchemeName string
+	Params     string
+}
+
+func (c RawCredential) Scheme() string {
+	return c.SchemeName
+}
+
+func (c RawCredential) Parameters() string {
+	return c.Params
+}
+
+// FormatCredential returns the Authorization header value of the given credential
+func FormatCredential(credential SchemeCredential) string {
+	return credential.Scheme() + " " + credential.Parameters()
+}
+
+// SchemeParser parses the parameters of an Authorization header (the part after the scheme)
+type SchemeParser func(parameters string) (Credential, error)
+
+var (
+	schemesMutex sync.RWMutex
+	schemes      = map[string]SchemeParser{}
+)
+
+// RegisterScheme registers (or replaces) the parser used by ParseCredential for the given scheme
+// scheme is case insensitive
+func RegisterScheme(scheme string, parser SchemeParser) {
+	schemesMutex.Lock()
+	defer schemesMutex.Unlock()
+	schemes[strings.ToLower(scheme)] = parser
+}
+
+// ParseCredential parses an Authorization header value with the parser registered for its scheme
+// a scheme without registered parser returns a RawCredential
+func ParseCredential(authorization string) (Credential, error) {
+	authorization = strings.TrimSpace(authorization)
+	if authorization == "" {
+		return nil, ErrMissingCredential
+	}
+	scheme, parameters, _ := strings.Cut(authorization, " ")
+	parameters = strings.TrimSpace(parameters)
+
+	schemesMutex.RLock()
+	parser, ok := schemes[strings.ToLower(scheme)]
+	schemesMutex.RUnlock()
+	if !ok {
+		return RawCredential{SchemeName: scheme, Params: parameters}, nil
+	}
+	return parser(parameters)
+}
+
+// ParseFromHeader returns the parsed Authorization (or X-Authorization) header credential
+// the raw header value is returned when it cannot be parsed, it lets the authenticator reject it
+func ParseFromHeader(request *http.Request) Credential {
+	raw, _ := ExtractFromHeader(request).(string)
+	if raw == "" {
+		return nil
+	}
+	credential, err := ParseCredential(raw)
+	if err != nil {
+		return raw
+	}
+	return credential
+}
+
+func parseBearer(parameters string) (Credential, error) {
+	if parameters == "" {
+		return nil, ErrInvalidCredential
+	}
+	return BearerToken(parameters), nil
+}
+
+func parseAPIKey(parameters string) (Credential, error) {
+	if parameters == "" {
+		return nil, ErrInvalidCredential
+	}
+	return APIKey(parameters), nil
+}
+
+func parseBasicCredential(parameters string) (Credential, error) {
+	decoded, err := base64.StdEncoding.DecodeString(parameters)
+	if err != nil {
+		return nil, ErrInvalidCredential
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return nil, ErrInvalidCredential
+	}
+	return BasicCredential{Username: username, Password: password}, nil
+}
+
+func init() {
+	RegisterScheme(BearerScheme, parseBearer)
+	RegisterScheme(APIKeyScheme, parseAPIKey)
+	RegisterScheme(BasicScheme, parseBasicCredential)
+	RegisterScheme(DigestScheme, parseDigestCredential)
+}
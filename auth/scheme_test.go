@@ -0,0 +1,96 @@
+package auth_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+type customCredential struct {
+	value string
+}
+
+func TestParseCredential(t *testing.T) {
+	auth.RegisterScheme("Custom", func(parameters string) (auth.Credential, error) {
+		if parameters == "" {
+			return nil, errors.New("my_custom_error")
+		}
+		return customCredential{value: strings.ToUpper(parameters)}, nil
+	})
+
+	tests := []struct {
+		authorization      string
+		expectedCredential auth.Credential
+		expectedErr        string
+	}{
+		{authorization: "", expectedErr: "missing credential"},
+		{authorization: "Bearer my_token", expectedCredential: auth.BearerToken("my_token")},
+		{authorization: "bearer  my_token ", expectedCredential: auth.BearerToken("my_token")},
+		{authorization: "Bearer", expectedErr: "invalid credential"},
+		{authorization: "ApiKey my_key", expectedCredential: auth.APIKey("my_key")},
+		{authorization: "Basic Zm9vOmJhcg==", expectedCredential: auth.BasicCredential{Username: "foo", Password: "bar"}},
+		{authorization: "Basic Zm9vOmJhcjpiYXo=", expectedCredential: auth.BasicCredential{Username: "foo", Password: "bar:baz"}},
+		{authorization: "Basic Zm9v", expectedErr: "invalid credential"},
+		{authorization: "Basic !!!", expectedErr: "invalid credential"},
+		{authorization: "Unknown my_value", expectedCredential: auth.RawCredential{SchemeName: "Unknown", Params: "my_value"}},
+		{authorization: "custom my_value", expectedCredential: customCredential{value: "MY_VALUE"}},
+		{authorization: "Custom", expectedErr: "my_custom_error"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			credential, err := auth.ParseCredential(tt.authorization)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCredential, credential)
+		})
+	}
+}
+
+func TestParseFromHeader(t *testing.T) {
+	tests := []struct {
+		request            *http.Request
+		expectedCredential auth.Credential
+	}{
+		{
+			request:            nil,
+			expectedCredential: nil,
+		},
+		{
+			request:            &http.Request{Header: http.Header{}},
+			expectedCredential: nil,
+		},
+		{
+			request: &http.Request{Header: http.Header{
+				"Authorization": []string{"Bearer my_token"},
+			}},
+			expectedCredential: auth.BearerToken("my_token"),
+		},
+		{
+			request: &http.Request{Header: http.Header{
+				"X-Authorization": []string{"Basic Zm9vOmJhcg=="},
+			}},
+			expectedCredential: auth.BasicCredential{Username: "foo", Password: "bar"},
+		},
+		{
+			request: &http.Request{Header: http.Header{
+				"Authorization": []string{"Basic !!!"},
+			}},
+			expectedCredential: "Basic !!!",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			assert.Equal(t, tt.expectedCredential, auth.ParseFromHeader(tt.request))
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+)
+
+const (
+	SourceHeader = "header"
+	SourceQuery  = "query"
+	SourceCookie = "cookie"
+	SourceForm   = "form"
+)
+
+// CredentialSource describes where the credential was found in the request
+// authenticators can use it to apply source specific rules (see CredentialSourceFromContext)
+type CredentialSource struct {
+	// Location is the part of the request holding the credential (SourceHeader, SourceQuery, SourceCookie or SourceForm)
+	Location string
+	// Name is the header, query parameter, cookie or form field name
+	Name string
+	// Method is the request method
+	Method string
+}
+
+type credentialSourceContextKey struct{}
+
+func CredentialSourceToContext(ctx context.Context, source CredentialSource) context.Context {
+	return context.WithValue(ctx, credentialSourceContextKey{}, source)
+}
+
+// CredentialSourceFromContext returns the source of the credential being authenticated, ok is false when it is unknown
+func CredentialSourceFromContext(ctx context.Context) (source CredentialSource, ok bool) {
+	if ctx == nil {
+		return CredentialSource{}, false
+	}
+	source, ok = ctx.Value(credentialSourceContextKey{}).(CredentialSource)
+	return source, ok
+}
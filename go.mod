@@ -1,17 +1,28 @@
 module github.com/gol4ng/httpware/v4
 
-go 1.10
+go 1.20
 
 require (
 	github.com/agiledragon/gomonkey/v2 v2.3.1
 	github.com/felixge/httpsnoop v1.0.3
 	github.com/prometheus/client_golang v1.14.0
 	github.com/stretchr/testify v1.8.2
+	golang.org/x/crypto v0.6.0
+	golang.org/x/sync v0.1.0
 )
 
 require (
+	github.com/beorn7/perks v1.0.1 // indirect
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.4 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/prometheus/client_model v0.3.0 // indirect
 	github.com/prometheus/common v0.41.0 // indirect
 	github.com/prometheus/procfs v0.9.0 // indirect
+	github.com/stretchr/objx v0.5.0 // indirect
 	golang.org/x/sys v0.6.0 // indirect
+	google.golang.org/protobuf v1.28.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"github.com/gol4ng/httpware/v4/auth"
+)
+
+const DefaultAPIKeyHeader = "X-Api-Key"
+
+// APIKeyCredentialFinder returns a CredentialFinder that looks for an auth.APIKey
+// in the configured header, query parameter and cookie (in this order)
+// the key is returned as a SourcedCredential, the authenticator gets its source (see auth.CredentialSourceFromContext)
+func APIKeyCredentialFinder(options ...APIKeyFinderOption) CredentialFinder {
+	config := NewAPIKeyFinderConfig(options...)
+	var finders []CredentialFinder
+	if config.header != "" {
+		finders = append(finders, FromHeader(config.header, ""))
+	}
+	if config.query != "" {
+		finders = append(finders, FromQuery(config.query))
+	}
+	if config.cookie != "" {
+		finders = append(finders, FromCookie(config.cookie))
+	}
+	return WithScheme(auth.APIKeyScheme, FirstFound(finders...))
+}
+
+// APIKeyFinderOption defines a APIKeyCredentialFinder configuration option
+type APIKeyFinderOption func(*APIKeyFinderConfig)
+
+type APIKeyFinderConfig struct {
+	header string
+	query  string
+	cookie string
+}
+
+func (o *APIKeyFinderConfig) apply(options ...APIKeyFinderOption) {
+	for _, option := range options {
+		option(o)
+	}
+}
+
+func NewAPIKeyFinderConfig(options ...APIKeyFinderOption) *APIKeyFinderConfig {
+	opts := &APIKeyFinderConfig{
+		header: DefaultAPIKeyHeader,
+	}
+	opts.apply(options...)
+	return opts
+}
+
+// WithAPIKeyHeader will configure the header option, an empty name disables it
+func WithAPIKeyHeader(name string) APIKeyFinderOption {
+	return func(config *APIKeyFinderConfig) {
+		config.header = name
+	}
+}
+
+// WithAPIKeyQuery will configure the query parameter option
+func WithAPIKeyQuery(param string) APIKeyFinderOption {
+	return func(config *APIKeyFinderConfig) {
+		config.query = param
+	}
+}
+
+// WithAPIKeyCookie will configure the cookie option
+func WithAPIKeyCookie(name string) APIKeyFinderOption {
+	return func(config *APIKeyFinderConfig) {
+		config.cookie = name
+	}
+}
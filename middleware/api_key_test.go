@@ -0,0 +1,86 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyCredentialFinder(t *testing.T) {
+	finder := middleware.APIKeyCredentialFinder(
+		middleware.WithAPIKeyQuery("api_key"),
+		middleware.WithAPIKeyCookie("api_key"),
+	)
+
+	tests := []struct {
+		header             string
+		query              string
+		cookie             string
+		expectedCredential auth.Credential
+	}{
+		{expectedCredential: nil},
+		{header: "my_header_key", query: "my_query_key", cookie: "my_cookie_key", expectedCredential: middleware.SourcedCredential{
+			Credential: auth.APIKey("my_header_key"),
+			Source:     auth.CredentialSource{Location: auth.SourceHeader, Name: middleware.DefaultAPIKeyHeader, Method: http.MethodGet},
+		}},
+		{query: "my_query_key", cookie: "my_cookie_key", expectedCredential: middleware.SourcedCredential{
+			Credential: auth.APIKey("my_query_key"),
+			Source:     auth.CredentialSource{Location: auth.SourceQuery, Name: "api_key", Method: http.MethodGet},
+		}},
+		{cookie: "my_cookie_key", expectedCredential: middleware.SourcedCredential{
+			Credential: auth.APIKey("my_cookie_key"),
+			Source:     auth.CredentialSource{Location: auth.SourceCookie, Name: "api_key", Method: http.MethodGet},
+		}},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "http://fake-addr/?api_key="+tt.query, nil)
+			if tt.header != "" {
+				request.Header.Set(middleware.DefaultAPIKeyHeader, tt.header)
+			}
+			if tt.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: "api_key", Value: tt.cookie})
+			}
+			assert.Equal(t, tt.expectedCredential, finder(request))
+		})
+	}
+}
+
+func TestAPIKeyCredentialFinder_Default(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "http://fake-addr/?api_key=my_query_key", nil)
+	assert.Nil(t, middleware.APIKeyCredentialFinder()(request))
+
+	request.Header.Set("X-Api-Key", "my_header_key")
+	assert.Equal(t, middleware.SourcedCredential{
+		Credential: auth.APIKey("my_header_key"),
+		Source:     auth.CredentialSource{Location: auth.SourceHeader, Name: "X-Api-Key", Method: http.MethodGet},
+	}, middleware.APIKeyCredentialFinder()(request))
+	assert.Nil(t, middleware.APIKeyCredentialFinder(middleware.WithAPIKeyHeader(""))(request))
+}
+
+func TestAuthentication_APIKey(t *testing.T) {
+	handler := middleware.Authentication(middleware.NewAuthenticateFunc(
+		auth.NewAPIKeyAuthenticator(auth.APIKeys{{Hash: auth.HashAPIKey("my_key"), Owner: "foo"}}),
+		middleware.WithCredentialFinder(middleware.APIKeyCredentialFinder()),
+	))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(auth.CredentialFromContext(request.Context()).(*auth.APIKeyEntry).Owner))
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	request.Header.Set("X-Api-Key", "my_key")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "foo", recorder.Body.String())
+
+	request.Header.Set("X-Api-Key", "my_unknown_key")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, "ApiKey", recorder.Header().Get("WWW-Authenticate"))
+}
@@ -1,10 +1,12 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gol4ng/httpware/v4"
 	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/rate_limit"
 )
 
 // Authentication middleware delegate the authentication process to the AuthenticateFunc
@@ -12,7 +14,21 @@ func Authentication(authenticateFunc AuthenticateFunc, options ...AuthOption) ht
 	config := NewAuthConfig(options...)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			if config.failureGuard != nil {
+				if err := config.failureGuard.Allow(req); err != nil && !config.lockoutCallback(err, writer, req) {
+					return
+				}
+			}
 			newReq, err := authenticateFunc(req)
+			if config.failureGuard != nil {
+				switch {
+				case err == nil:
+					config.failureGuard.Success(req)
+				// only the rejected credentials are failed attempts, not the anonymous requests nor the backend errors
+				case errors.Is(err, auth.ErrInvalidCredential):
+					config.failureGuard.Failure(req)
+				}
+			}
 			if err == nil {
 				config.successMiddleware(next).ServeHTTP(writer, newReq)
 				return
@@ -36,6 +52,8 @@ type AuthOption func(*AuthConfig)
 type AuthConfig struct {
 	errorHandler      ErrorHandler
 	successMiddleware httpware.Middleware
+	failureGuard      *rate_limit.FailureGuard
+	lockoutCallback   RateLimitErrorCallback
 }
 
 func (o *AuthConfig) apply(options ...AuthOption) {
@@ -48,13 +66,21 @@ func NewAuthConfig(options ...AuthOption) *AuthConfig {
 	opts := &AuthConfig{
 		errorHandler:      DefaultErrorHandler,
 		successMiddleware: httpware.NopMiddleware,
+		lockoutCallback:   DefaultRateLimitErrorCallback,
 	}
 	opts.apply(options...)
 	return opts
 }
 
+// DefaultErrorHandler responds 403 for auth.ErrForbidden errors, 503 for auth.ErrUnavailable errors and 401 otherwise
+// with one WWW-Authenticate challenge per auth.Error found in the error
+// the body is the status text, the error message is never sent to the client
 func DefaultErrorHandler(err error, writer http.ResponseWriter, _ *http.Request) bool {
-	http.Error(writer, err.Error(), http.StatusUnauthorized)
+	for _, challenge := range auth.Challenges(err) {
+		writer.Header().Add("WWW-Authenticate", challenge)
+	}
+	status := auth.StatusCode(err)
+	http.Error(writer, http.StatusText(status), status)
 	return true
 }
 
@@ -72,12 +98,32 @@ func WithSuccessMiddleware(middleware httpware.Middleware) AuthOption {
 	}
 }
 
+// WithFailureGuard will configure failureGuard option
+// the failed attempts are counted by the guard, locked clients are rejected with a 429 before the authentication
+func WithFailureGuard(guard *rate_limit.FailureGuard) AuthOption {
+	return func(config *AuthConfig) {
+		config.failureGuard = guard
+	}
+}
+
+// WithLockoutCallback will configure lockoutCallback option
+// the callback is called with the rate_limit.LimitError of the locked clients, the authentication goes on when it returns true
+func WithLockoutCallback(callback RateLimitErrorCallback) AuthOption {
+	return func(config *AuthConfig) {
+		config.lockoutCallback = callback
+	}
+}
+
 // NewAuthenticateFunc is an AuthenticateFunc that find, authenticate and hydrate credentials on the request context
 func NewAuthenticateFunc(authenticator auth.Authenticator, options ...AuthFuncOption) AuthenticateFunc {
 	config := NewAuthFuncConfig(options...)
 	return func(request *http.Request) (*http.Request, error) {
 		ctx := request.Context()
 		credential := config.credentialFinder(request)
+		if source, ok := credential.(SourcedCredential); ok {
+			ctx = auth.CredentialSourceToContext(ctx, source.Source)
+			credential = source.Credential
+		}
 		if authenticator != nil {
 			creds, err := authenticator.Authenticate(ctx, credential)
 			if err != nil {
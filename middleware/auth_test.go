@@ -11,6 +11,7 @@ import (
 	"github.com/gol4ng/httpware/v4/auth"
 	"github.com/gol4ng/httpware/v4/middleware"
 	"github.com/gol4ng/httpware/v4/mocks"
+	"github.com/gol4ng/httpware/v4/rate_limit"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -59,7 +60,60 @@ func TestDefaultErrorHandler(t *testing.T) {
 	middleware.DefaultErrorHandler(errors.New("my_fake_error"), response, request)
 
 	assert.Equal(t, http.StatusUnauthorized, response.Code)
-	assert.Equal(t, "my_fake_error\n", response.Body.String())
+	assert.Equal(t, "Unauthorized\n", response.Body.String())
+}
+
+func TestDefaultErrorHandler_Challenge(t *testing.T) {
+	request, _ := http.NewRequest("", "", nil)
+	response := httptest.NewRecorder()
+
+	middleware.DefaultErrorHandler(&auth.Error{Err: auth.ErrInvalidCredential, Scheme: "Basic", Realm: "my_realm"}, response, request)
+
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Equal(t, `Basic realm="my_realm"`, response.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, "Unauthorized\n", response.Body.String())
+}
+
+func TestDefaultErrorHandler_TypedErrors(t *testing.T) {
+	tests := []struct {
+		err               error
+		expectedStatus    int
+		expectedChallenge string
+	}{
+		{
+			err:               &auth.Error{Err: auth.ErrMissingCredential, Scheme: auth.BearerScheme, Realm: "my_realm"},
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer realm="my_realm"`,
+		},
+		{
+			err:               &auth.Error{Err: fmt.Errorf("%w: my_internal_error", auth.ErrInvalidCredential), Scheme: auth.BearerScheme, Realm: "my_realm", Description: "invalid token"},
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer realm="my_realm", error="invalid_token", error_description="invalid token"`,
+		},
+		{
+			err:               &auth.Error{Err: auth.ErrExpired, Scheme: auth.BearerScheme, Description: "token expired"},
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer error="invalid_token", error_description="token expired"`,
+		},
+		{
+			err:               &auth.Error{Err: auth.ErrForbidden, Scheme: auth.BearerScheme, Params: map[string]string{"scope": "write"}},
+			expectedStatus:    http.StatusForbidden,
+			expectedChallenge: `Bearer error="insufficient_scope", scope="write"`,
+		},
+		{
+			err:            fmt.Errorf("%w: my_internal_error", auth.ErrForbidden),
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			assert.True(t, middleware.DefaultErrorHandler(tt.err, recorder, httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)))
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			assert.Equal(t, tt.expectedChallenge, recorder.Header().Get("WWW-Authenticate"))
+			assert.Equal(t, http.StatusText(tt.expectedStatus)+"\n", recorder.Body.String())
+		})
+	}
 }
 
 func TestAuthentication(t *testing.T) {
@@ -156,7 +210,7 @@ func TestAuthentication_Unauthorize(t *testing.T) {
 	assert.False(t, handlerCalled)
 	assert.Equal(t, nil, auth.CredentialFromContext(innerContext))
 	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
-	assert.Equal(t, "my_authenticated_error\n", recorder.Body.String())
+	assert.Equal(t, "Unauthorized\n", recorder.Body.String())
 }
 
 func TestNewAuthenticateFunc(t *testing.T) {
@@ -211,3 +265,107 @@ func TestNewAuthenticateFunc_Error(t *testing.T) {
 
 	authenticator.AssertExpectations(t)
 }
+
+func TestAuthentication_SchemeRouter(t *testing.T) {
+	handler := middleware.Authentication(middleware.NewAuthenticateFunc(
+		auth.SchemeRouter{
+			auth.BasicScheme:  auth.NewBasicAuthenticator("my_realm", auth.Passwords{"foo": "{SHA}XrlCgQp168hQlyqJKF1XDUhMicQ="}),
+			auth.APIKeyScheme: auth.NewAPIKeyAuthenticator(auth.APIKeys{{Hash: auth.HashAPIKey("my_key"), Owner: "bar"}}),
+		},
+		middleware.WithCredentialFinder(func(request *http.Request) auth.Credential {
+			if key := request.Header.Get(middleware.DefaultAPIKeyHeader); key != "" {
+				return auth.APIKey(key)
+			}
+			return auth.ParseFromHeader(request)
+		}),
+	))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		principal := auth.PrincipalFromContext(request.Context())
+		_, _ = writer.Write([]byte(principal.Subject() + " " + principal.AuthMethod()))
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	request.SetBasicAuth("foo", "my_password")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "foo basic", recorder.Body.String())
+
+	request = httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	request.Header.Set(middleware.DefaultAPIKeyHeader, "my_key")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "bar api_key", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://fake-addr", nil))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestDefaultErrorHandler_Challenges(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	middleware.DefaultErrorHandler(auth.Errors{
+		&auth.Error{Err: auth.ErrMissingCredential, Scheme: auth.BearerScheme},
+		&auth.Error{Err: auth.ErrMissingCredential, Scheme: auth.BasicScheme, Realm: "my_realm"},
+	}, recorder, httptest.NewRequest(http.MethodGet, "http://fake-addr", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, []string{"Bearer", `Basic realm="my_realm"`}, recorder.Header().Values("WWW-Authenticate"))
+}
+
+func TestAuthentication_FailureGuard(t *testing.T) {
+	var lockouts []rate_limit.LockoutEvent
+	guard := rate_limit.NewFailureGuard(
+		rate_limit.WithMaxFailures(3),
+		rate_limit.WithLockoutHook(func(event rate_limit.LockoutEvent) {
+			lockouts = append(lockouts, event)
+		}),
+	)
+	authenticator := auth.AuthenticatorFunc(func(_ context.Context, credential auth.Credential) (auth.Credential, error) {
+		if credential == nil {
+			return nil, auth.ErrMissingCredential
+		}
+		basic, ok := credential.(auth.BasicCredential)
+		if ok && basic.Password == "password" {
+			return credential, nil
+		}
+		if ok && basic.Password == "unavailable" {
+			return nil, errors.New("backend unavailable")
+		}
+		return nil, auth.ErrInvalidCredential
+	})
+	handler := middleware.Authentication(
+		middleware.NewAuthenticateFunc(authenticator, middleware.WithCredentialFinder(auth.ParseFromHeader)),
+		middleware.WithFailureGuard(guard),
+		middleware.WithLockoutCallback(func(err error, writer http.ResponseWriter, req *http.Request) bool {
+			writer.Header().Set("X-Locked", "true")
+			return middleware.DefaultRateLimitErrorCallback(err, writer, req)
+		}),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {}))
+
+	serve := func(username string, password string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+		if username != "" {
+			request.SetBasicAuth(username, password)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// anonymous requests and backend errors are not counted
+	assert.Equal(t, http.StatusUnauthorized, serve("", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("foo", "unavailable").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("foo", "wrong").Code)
+	assert.Equal(t, http.StatusOK, serve("foo", "password").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("foo", "wrong").Code)
+	assert.Empty(t, lockouts)
+	assert.Equal(t, http.StatusUnauthorized, serve("bar", "wrong").Code)
+	assert.Len(t, lockouts, 1)
+
+	recorder := serve("foo", "password")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.NotEmpty(t, recorder.Header().Get("Retry-After"))
+	assert.Equal(t, "true", recorder.Header().Get("X-Locked"))
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gol4ng/httpware/v4"
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/auth/policy"
+)
+
+// Authorize middleware evaluates the policy against the credential stored in the request context
+// it must be used after the Authentication middleware
+// the errors are *auth.Error carrying the configured challenge (Bearer by default) and the policy parameters
+func Authorize(policy policy.Policy, options ...AuthorizeOption) httpware.Middleware {
+	config := NewAuthorizeConfig(options...)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			credential := auth.CredentialFromContext(req.Context())
+			var err error
+			if credential == nil || credential == "" {
+				err = auth.ErrMissingCredential
+			} else {
+				err = policy(req, credential)
+			}
+			if err != nil && config.ErrorHandler(config.challenge(err), writer, req) {
+				return
+			}
+			next.ServeHTTP(writer, req)
+		})
+	}
+}
+
+// AuthorizeOption defines a authorize middleware configuration option
+type AuthorizeOption func(*AuthorizeConfig)
+
+type AuthorizeConfig struct {
+	// Scheme and Realm of the challenge sent back when the request is not authorized
+	Scheme       string
+	Realm        string
+	ErrorHandler ErrorHandler
+}
+
+// challenge returns a new *auth.Error with the configured scheme and realm,
+// the error and the parameters of the policy *auth.Error are copied, the realm of the policy is kept when none is configured
+func (c *AuthorizeConfig) challenge(err error) error {
+	authErr := &auth.Error{}
+	if !errors.As(err, &authErr) {
+		return &auth.Error{Err: err, Scheme: c.Scheme, Realm: c.Realm}
+	}
+	challenge := &auth.Error{
+		Err:         authErr.Err,
+		Scheme:      c.Scheme,
+		Realm:       c.Realm,
+		Description: authErr.Description,
+	}
+	if challenge.Realm == "" {
+		challenge.Realm = authErr.Realm
+	}
+	if authErr.Params != nil {
+		challenge.Params = make(map[string]string, len(authErr.Params))
+		for name, value := range authErr.Params {
+			challenge.Params[name] = value
+		}
+	}
+	return challenge
+}
+
+func (c *AuthorizeConfig) apply(options ...AuthorizeOption) *AuthorizeConfig {
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// NewAuthorizeConfig returns a new authorize configuration with all options applied
+func NewAuthorizeConfig(options ...AuthorizeOption) *AuthorizeConfig {
+	config := &AuthorizeConfig{
+		Scheme:       auth.BearerScheme,
+		ErrorHandler: DefaultAuthorizeErrorHandler,
+	}
+	return config.apply(options...)
+}
+
+// DefaultAuthorizeErrorHandler responds 403 when the policy denied the access and 401 when the request is not authenticated
+func DefaultAuthorizeErrorHandler(err error, writer http.ResponseWriter, req *http.Request) bool {
+	return DefaultErrorHandler(err, writer, req)
+}
+
+// WithAuthorizeChallenge will configure Scheme and Realm options
+func WithAuthorizeChallenge(scheme string, realm string) AuthorizeOption {
+	return func(config *AuthorizeConfig) {
+		config.Scheme = scheme
+		config.Realm = realm
+	}
+}
+
+// WithAuthorizeErrorHandler will configure ErrorHandler option
+func WithAuthorizeErrorHandler(errorHandler ErrorHandler) AuthorizeOption {
+	return func(config *AuthorizeConfig) {
+		config.ErrorHandler = errorHandler
+	}
+}
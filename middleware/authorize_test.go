@@ -0,0 +1,85 @@
+package middleware_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/auth/policy"
+	"github.com/gol4ng/httpware/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		credential        auth.Credential
+		expectedStatus    int
+		expectedBody      string
+		expectedChallenge string
+	}{
+		{credential: nil, expectedStatus: http.StatusUnauthorized, expectedBody: "Unauthorized\n", expectedChallenge: `Bearer realm="my_realm"`},
+		{credential: "", expectedStatus: http.StatusUnauthorized, expectedBody: "Unauthorized\n", expectedChallenge: `Bearer realm="my_realm"`},
+		{credential: &auth.APIKeyEntry{Scope: "read"}, expectedStatus: http.StatusForbidden, expectedBody: "Forbidden\n", expectedChallenge: `Bearer realm="my_realm", error="insufficient_scope", scope="write"`},
+		{credential: &auth.APIKeyEntry{Scope: "read write"}, expectedStatus: http.StatusOK, expectedBody: "OK"},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			handler := middleware.Authorize(policy.RequireScopes("write"), middleware.WithAuthorizeChallenge(auth.BearerScheme, "my_realm"))(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+				_, _ = writer.Write([]byte("OK"))
+			}))
+			request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+			request = request.WithContext(auth.CredentialToContext(context.Background(), tt.credential))
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			assert.Equal(t, tt.expectedBody, recorder.Body.String())
+			assert.Equal(t, tt.expectedChallenge, recorder.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+func TestAuthorize_CustomErrorHandler(t *testing.T) {
+	var handlerErr error
+	handler := middleware.Authorize(
+		policy.RequireRoles("admin"),
+		middleware.WithAuthorizeErrorHandler(func(err error, writer http.ResponseWriter, _ *http.Request) bool {
+			handlerErr = err
+			writer.WriteHeader(http.StatusNotFound)
+			return true
+		}),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		assert.Fail(t, "should not be called")
+	}))
+	request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	request = request.WithContext(auth.CredentialToContext(context.Background(), "my_credential"))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.EqualError(t, handlerErr, "forbidden: credential has no role")
+}
+
+func TestAuthorize_Challenge(t *testing.T) {
+	denial := &auth.Error{Err: auth.ErrForbidden, Scheme: auth.BearerScheme, Params: map[string]string{"scope": "write"}}
+	handler := middleware.Authorize(
+		func(_ *http.Request, _ auth.Credential) error {
+			return denial
+		},
+		middleware.WithAuthorizeChallenge(auth.BasicScheme, "my_realm"),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		assert.Fail(t, "should not be called")
+	}))
+	request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	request = request.WithContext(auth.CredentialToContext(context.Background(), "my_credential"))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Equal(t, `Basic realm="my_realm", scope="write"`, recorder.Header().Get("WWW-Authenticate"))
+	// the policy error is not modified
+	assert.Equal(t, &auth.Error{Err: auth.ErrForbidden, Scheme: auth.BearerScheme, Params: map[string]string{"scope": "write"}}, denial)
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gol4ng/httpware/v4/auth"
+)
+
+// ClientCertificateCredentialFinder returns the TLS client certificate
+// behind an internal hop it returns the auth.SignedClientIdentity sent in the auth.ClientIdentityHeader
+func ClientCertificateCredentialFinder(request *http.Request) auth.Credential {
+	if certificate := auth.ClientCertificateFromRequest(request); certificate != nil {
+		return certificate
+	}
+	if signed := request.Header.Get(auth.ClientIdentityHeader); signed != "" {
+		return auth.SignedClientIdentity(signed)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package middleware_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/middleware"
+	"github.com/gol4ng/httpware/v4/tripperware"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTLSCertificate(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (tls.Certificate, *x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, certificate, key
+}
+
+func TestClientCertificateCredentialFinder(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	assert.Nil(t, middleware.ClientCertificateCredentialFinder(request))
+
+	request.Header.Set(auth.ClientIdentityHeader, "my_signed_identity")
+	assert.Equal(t, auth.SignedClientIdentity("my_signed_identity"), middleware.ClientCertificateCredentialFinder(request))
+}
+
+func TestAuthentication_ClientCertificate(t *testing.T) {
+	_, ca, caKey := newTLSCertificate(t, "my_ca", nil, nil)
+	clientCertificate, _, _ := newTLSCertificate(t, "my_client", ca, caKey)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	identityKey := []byte("my_identity_key")
+
+	// the internal service trusts the identity signed by the edge service
+	internal := httptest.NewServer(middleware.Authentication(middleware.NewAuthenticateFunc(
+		auth.NewClientCertificateAuthenticator(auth.WithSignedIdentity(identityKey, time.Minute)),
+		middleware.WithCredentialFinder(middleware.ClientCertificateCredentialFinder),
+	))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte("internal " + auth.CredentialFromContext(request.Context()).(*auth.ClientIdentity).CommonName))
+	})))
+	defer internal.Close()
+
+	internalClient := &http.Client{Transport: tripperware.ClientIdentityForwarder(identityKey)(http.DefaultTransport)}
+	edge := httptest.NewUnstartedServer(middleware.Authentication(middleware.NewAuthenticateFunc(
+		auth.NewClientCertificateAuthenticator(auth.WithAllowedCommonNames("my_client")),
+		middleware.WithCredentialFinder(middleware.ClientCertificateCredentialFinder),
+	))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		internalRequest, _ := http.NewRequest(http.MethodGet, internal.URL, nil)
+		response, err := internalClient.Do(internalRequest.WithContext(request.Context()))
+		assert.NoError(t, err)
+		body, _ := ioutil.ReadAll(response.Body)
+		_, _ = writer.Write(body)
+	})))
+	edge.TLS = &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: clientCAs}
+	edge.StartTLS()
+	defer edge.Close()
+
+	client := edge.Client()
+	response, err := client.Get(edge.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	client.Transport.(*http.Transport).TLSClientConfig.Certificates = []tls.Certificate{clientCertificate}
+	response, err = client.Get(edge.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	body, _ := ioutil.ReadAll(response.Body)
+	assert.Equal(t, "internal my_client", string(body))
+
+	// the identity header cannot be forged without the key
+	forged, _ := auth.SignClientIdentity([]byte("other_key"), auth.ClientIdentity{CommonName: "my_client"})
+	request, _ := http.NewRequest(http.MethodGet, internal.URL, nil)
+	request.Header.Set(auth.ClientIdentityHeader, string(forged))
+	response, err = http.DefaultClient.Do(request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
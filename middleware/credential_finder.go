@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gol4ng/httpware/v4/auth"
+)
+
+// SourcedCredential is the credential returned by the source aware finders (FromHeader, FromQuery, FromCookie and FromForm)
+// NewAuthenticateFunc gives the Credential to the authenticator with the Source in the context (see auth.CredentialSourceFromContext)
+type SourcedCredential struct {
+	Credential auth.Credential
+	Source     auth.CredentialSource
+}
+
+// FromHeader returns a CredentialFinder that looks for the credential in the given header
+// when scheme is not empty the header must use this scheme and the parsed credential is returned (see auth.ParseCredential),
+// otherwise the raw header value is returned
+func FromHeader(name string, scheme string) CredentialFinder {
+	return func(request *http.Request) auth.Credential {
+		value := request.Header.Get(name)
+		if value == "" {
+			return nil
+		}
+		var credential auth.Credential = value
+		if scheme != "" {
+			if len(value) <= len(scheme) || !strings.EqualFold(value[:len(scheme)], scheme) || value[len(scheme)] != ' ' {
+				return nil
+			}
+			if parsed, err := auth.ParseCredential(value); err == nil {
+				credential = parsed
+			}
+		}
+		return sourced(request, credential, auth.SourceHeader, name)
+	}
+}
+
+// FromQuery returns a CredentialFinder that looks for the raw credential in the given query parameter
+func FromQuery(param string) CredentialFinder {
+	return func(request *http.Request) auth.Credential {
+		value := request.URL.Query().Get(param)
+		if value == "" {
+			return nil
+		}
+		return sourced(request, value, auth.SourceQuery, param)
+	}
+}
+
+// FromCookie returns a CredentialFinder that looks for the raw credential in the given cookie
+func FromCookie(name string) CredentialFinder {
+	return func(request *http.Request) auth.Credential {
+		cookie, err := request.Cookie(name)
+		if err != nil || cookie.Value == "" {
+			return nil
+		}
+		return sourced(request, cookie.Value, auth.SourceCookie, name)
+	}
+}
+
+// FromForm returns a CredentialFinder that looks for the raw credential in the given form field of the request body
+// the request body is parsed (see http.Request.PostFormValue)
+func FromForm(field string) CredentialFinder {
+	return func(request *http.Request) auth.Credential {
+		value := request.PostFormValue(field)
+		if value == "" {
+			return nil
+		}
+		return sourced(request, value, auth.SourceForm, field)
+	}
+}
+
+// FirstFound returns a CredentialFinder that returns the first credential found by the given finders
+func FirstFound(finders ...CredentialFinder) CredentialFinder {
+	return func(request *http.Request) auth.Credential {
+		for _, finder := range finders {
+			if credential := finder(request); credential != nil && credential != "" {
+				return credential
+			}
+		}
+		return nil
+	}
+}
+
+// WithScheme returns a CredentialFinder that parses the raw credential found by the finder as a credential of the given scheme
+// ie: WithScheme(auth.BearerScheme, FromQuery("access_token")) returns an auth.BearerToken
+func WithScheme(scheme string, finder CredentialFinder) CredentialFinder {
+	return func(request *http.Request) auth.Credential {
+		credential := finder(request)
+		source, ok := credential.(SourcedCredential)
+		if ok {
+			credential = source.Credential
+		}
+		if raw, isString := credential.(string); isString && raw != "" {
+			if parsed, err := auth.ParseCredential(scheme + " " + raw); err == nil {
+				credential = parsed
+			}
+		}
+		if ok {
+			source.Credential = credential
+			return source
+		}
+		return credential
+	}
+}
+
+func sourced(request *http.Request, credential auth.Credential, location string, name string) SourcedCredential {
+	return SourcedCredential{
+		Credential: credential,
+		Source:     auth.CredentialSource{Location: location, Name: name, Method: request.Method},
+	}
+}
@@ -0,0 +1,135 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialFinders(t *testing.T) {
+	tests := []struct {
+		finder             middleware.CredentialFinder
+		request            func() *http.Request
+		expectedCredential auth.Credential
+	}{
+		{
+			finder: middleware.FromHeader("Authorization", auth.BearerScheme),
+			request: func() *http.Request {
+				request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+				request.Header.Set("Authorization", "bearer my_token")
+				return request
+			},
+			expectedCredential: middleware.SourcedCredential{Credential: auth.BearerToken("my_token"), Source: auth.CredentialSource{Location: auth.SourceHeader, Name: "Authorization", Method: http.MethodGet}},
+		},
+		{
+			finder: middleware.FromHeader("Authorization", auth.BearerScheme),
+			request: func() *http.Request {
+				request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+				request.Header.Set("Authorization", "Basic Zm9vOmJhcg==")
+				return request
+			},
+		},
+		{
+			finder: middleware.FromHeader("X-Token", ""),
+			request: func() *http.Request {
+				request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+				request.Header.Set("X-Token", "my_token")
+				return request
+			},
+			expectedCredential: middleware.SourcedCredential{Credential: "my_token", Source: auth.CredentialSource{Location: auth.SourceHeader, Name: "X-Token", Method: http.MethodGet}},
+		},
+		{
+			finder: middleware.FromQuery("access_token"),
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "http://fake-addr?access_token=my_token", nil)
+			},
+			expectedCredential: middleware.SourcedCredential{Credential: "my_token", Source: auth.CredentialSource{Location: auth.SourceQuery, Name: "access_token", Method: http.MethodGet}},
+		},
+		{
+			finder: middleware.WithScheme(auth.BearerScheme, middleware.FromQuery("access_token")),
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "http://fake-addr?access_token=my_token", nil)
+			},
+			expectedCredential: middleware.SourcedCredential{Credential: auth.BearerToken("my_token"), Source: auth.CredentialSource{Location: auth.SourceQuery, Name: "access_token", Method: http.MethodGet}},
+		},
+		{
+			finder: middleware.FromCookie("token"),
+			request: func() *http.Request {
+				request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+				request.AddCookie(&http.Cookie{Name: "token", Value: "my_token"})
+				return request
+			},
+			expectedCredential: middleware.SourcedCredential{Credential: "my_token", Source: auth.CredentialSource{Location: auth.SourceCookie, Name: "token", Method: http.MethodGet}},
+		},
+		{
+			finder: middleware.FromForm("token"),
+			request: func() *http.Request {
+				request := httptest.NewRequest(http.MethodPost, "http://fake-addr", strings.NewReader("token=my_token"))
+				request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return request
+			},
+			expectedCredential: middleware.SourcedCredential{Credential: "my_token", Source: auth.CredentialSource{Location: auth.SourceForm, Name: "token", Method: http.MethodPost}},
+		},
+		{
+			finder: middleware.FirstFound(middleware.FromHeader("Authorization", auth.BearerScheme), middleware.FromQuery("access_token")),
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "http://fake-addr?access_token=my_token", nil)
+			},
+			expectedCredential: middleware.SourcedCredential{Credential: "my_token", Source: auth.CredentialSource{Location: auth.SourceQuery, Name: "access_token", Method: http.MethodGet}},
+		},
+		{
+			finder: middleware.FirstFound(middleware.FromHeader("Authorization", auth.BearerScheme), middleware.FromQuery("access_token")),
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			assert.Equal(t, tt.expectedCredential, tt.finder(tt.request()))
+		})
+	}
+}
+
+func TestCredentialFinders_SourceRule(t *testing.T) {
+	// query string tokens are only accepted on GET requests
+	authenticator := auth.AuthenticatorFunc(func(ctx context.Context, credential auth.Credential) (auth.Credential, error) {
+		if source, ok := auth.CredentialSourceFromContext(ctx); ok && source.Location == auth.SourceQuery && source.Method != http.MethodGet {
+			return nil, errors.New("query token not allowed")
+		}
+		return credential, nil
+	})
+	handler := middleware.Authentication(middleware.NewAuthenticateFunc(
+		authenticator,
+		middleware.WithCredentialFinder(middleware.FirstFound(
+			middleware.FromHeader(auth.AuthorizationHeader, auth.BearerScheme),
+			middleware.WithScheme(auth.BearerScheme, middleware.FromQuery("access_token")),
+		)),
+	))(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		_, _ = writer.Write([]byte(auth.CredentialFromContext(req.Context()).(auth.BearerToken)))
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://fake-addr?access_token=my_token", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "my_token", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "http://fake-addr?access_token=my_token", nil))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	request := httptest.NewRequest(http.MethodPost, "http://fake-addr", nil)
+	request.Header.Set(auth.AuthorizationHeader, "Bearer my_token")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
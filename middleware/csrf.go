@@ -0,0 +1,322 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gol4ng/httpware/v4"
+	"github.com/gol4ng/httpware/v4/session"
+	"github.com/gol4ng/httpware/v4/skip"
+)
+
+const csrfTokenLength = 32
+
+var (
+	// ErrCSRFTokenMissing is given to the error callback when the unsafe request has no token
+	ErrCSRFTokenMissing = errors.New("csrf token missing")
+	// ErrCSRFTokenInvalid is given to the error callback when the request token does not match
+	ErrCSRFTokenInvalid = errors.New("csrf token invalid")
+	// ErrCSRFOriginMismatch is given to the error callback when the request comes from an untrusted origin
+	ErrCSRFOriginMismatch = errors.New("csrf origin mismatch")
+)
+
+type csrfContextKey struct{}
+
+// CSRF middleware protects the unsafe requests (other than GET, HEAD, OPTIONS and TRACE) against cross site request forgery
+// the Origin (or Referer) header must match the request scheme and host or a trusted origin,
+// a HTTPS request must send one of them, and the request must send the token in the header or the form field
+// the token is stored in a cookie (double submit cookie) or in the request session (synchronizer token, see WithCSRFSession)
+// a new token is only issued once the request is verified
+// use CSRFToken to render the token in the templates
+func CSRF(options ...CSRFOption) httpware.Middleware {
+	config := NewCSRFConfig(options...)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			token := config.currentToken(req)
+			if !isSafeMethod(req.Method) && (config.Exempt == nil || !config.Exempt(req)) {
+				if err := config.verify(req, token); err != nil {
+					config.ErrorCallback(err, writer, req)
+					return
+				}
+			}
+			if token == nil {
+				var err error
+				if token, err = config.issueToken(writer, req); err != nil {
+					config.ErrorCallback(err, writer, req)
+					return
+				}
+			}
+			if token != nil {
+				req = req.WithContext(context.WithValue(req.Context(), csrfContextKey{}, token))
+			}
+			next.ServeHTTP(writer, req)
+		})
+	}
+}
+
+// CSRFToken returns the masked token of the request to put in the forms or the request headers
+// the token is masked with a random pad on each call to prevent BREACH attacks
+func CSRFToken(req *http.Request) string {
+	token, ok := req.Context().Value(csrfContextKey{}).([]byte)
+	if !ok {
+		return ""
+	}
+	pad := make([]byte, csrfTokenLength)
+	if _, err := rand.Read(pad); err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(append(pad, xorBytes(pad, token)...))
+}
+
+// currentToken returns the token of the request cookie or session, nil when there is none
+func (c *CSRFConfig) currentToken(req *http.Request) []byte {
+	encoded := ""
+	if c.Session {
+		if s := session.FromContext(req.Context()); s != nil {
+			encoded = s.Get(c.FormField)
+		}
+	} else if cookie, err := req.Cookie(c.CookieName); err == nil {
+		encoded = cookie.Value
+	}
+	if token, err := base64.RawURLEncoding.DecodeString(encoded); err == nil && len(token) == csrfTokenLength {
+		return token
+	}
+	return nil
+}
+
+// issueToken generates a new token and stores it in the session or the cookie
+// it returns nil when the session mode is used without session
+func (c *CSRFConfig) issueToken(writer http.ResponseWriter, req *http.Request) ([]byte, error) {
+	var s *session.Session
+	if c.Session {
+		if s = session.FromContext(req.Context()); s == nil {
+			return nil, nil
+		}
+	}
+	token, err := newCSRFToken()
+	if err != nil {
+		return nil, err
+	}
+	if s != nil {
+		s.Set(c.FormField, base64.RawURLEncoding.EncodeToString(token))
+		return token, nil
+	}
+	http.SetCookie(writer, &http.Cookie{
+		Name:     c.CookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(token),
+		Path:     c.CookiePath,
+		Domain:   c.CookieDomain,
+		Secure:   c.CookieSecure,
+		SameSite: c.CookieSameSite,
+		// the client scripts read the cookie to send the token in the header
+		HttpOnly: false,
+	})
+	return token, nil
+}
+
+func (c *CSRFConfig) verify(req *http.Request, token []byte) error {
+	if err := c.verifyOrigin(req); err != nil {
+		return err
+	}
+	sent := req.Header.Get(c.HeaderName)
+	if sent == "" && c.FormField != "" {
+		sent = req.PostFormValue(c.FormField)
+	}
+	if sent == "" {
+		return ErrCSRFTokenMissing
+	}
+	if token == nil || subtle.ConstantTimeCompare(unmaskCSRFToken(sent), token) != 1 {
+		return ErrCSRFTokenInvalid
+	}
+	return nil
+}
+
+// verifyOrigin checks the scheme and the host of the Origin header, or of the Referer header when the Origin is missing
+// a HTTPS request without any of them is rejected
+// behind a TLS terminating proxy the request is seen as HTTP, the HTTPS origins must be trusted (see WithTrustedOrigins)
+func (c *CSRFConfig) verifyOrigin(req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		origin = req.Referer()
+	}
+	if origin == "" {
+		if req.TLS != nil {
+			return ErrCSRFOriginMismatch
+		}
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return ErrCSRFOriginMismatch
+	}
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	if strings.EqualFold(u.Scheme, scheme) && strings.EqualFold(u.Host, req.Host) {
+		return nil
+	}
+	for _, trusted := range c.TrustedOrigins {
+		if strings.EqualFold(trusted, u.Scheme+"://"+u.Host) {
+			return nil
+		}
+	}
+	return ErrCSRFOriginMismatch
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func newCSRFToken() ([]byte, error) {
+	token := make([]byte, csrfTokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// unmaskCSRFToken accepts a masked token (see CSRFToken) or the raw token of the cookie
+func unmaskCSRFToken(value string) []byte {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	switch len(decoded) {
+	case csrfTokenLength:
+		return decoded
+	case 2 * csrfTokenLength:
+		return xorBytes(decoded[:csrfTokenLength], decoded[csrfTokenLength:])
+	}
+	return nil
+}
+
+func xorBytes(a []byte, b []byte) []byte {
+	result := make([]byte, len(a))
+	for i := range a {
+		result[i] = a[i] ^ b[i]
+	}
+	return result
+}
+
+type CSRFErrorCallback func(err error, writer http.ResponseWriter, req *http.Request)
+
+// CSRFOption defines a CSRF middleware configuration option
+type CSRFOption func(*CSRFConfig)
+
+type CSRFConfig struct {
+	// Session stores the token in the request session instead of a cookie, the Session middleware must be used before
+	Session        bool
+	CookieName     string
+	CookiePath     string
+	CookieDomain   string
+	CookieSecure   bool
+	CookieSameSite http.SameSite
+	// HeaderName is the request header holding the token
+	HeaderName string
+	// FormField is the form field holding the token, it is also the session key of the token
+	FormField string
+	// TrustedOrigins are the origins ("<scheme>://<host>") allowed in addition to the request host
+	TrustedOrigins []string
+	// Exempt skips the verification of the matching requests
+	Exempt        skip.Condition
+	ErrorCallback CSRFErrorCallback
+}
+
+func (c *CSRFConfig) apply(options ...CSRFOption) *CSRFConfig {
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// NewCSRFConfig returns a new CSRF configuration with all options applied
+func NewCSRFConfig(options ...CSRFOption) *CSRFConfig {
+	config := &CSRFConfig{
+		CookieName:     "csrf_token",
+		CookiePath:     "/",
+		CookieSecure:   true,
+		CookieSameSite: http.SameSiteLaxMode,
+		HeaderName:     "X-CSRF-Token",
+		FormField:      "csrf_token",
+		ErrorCallback:  DefaultCSRFErrorCallback,
+	}
+	return config.apply(options...)
+}
+
+// DefaultCSRFErrorCallback responds 403 when the request is not verified, 500 when the token cannot be generated
+func DefaultCSRFErrorCallback(err error, writer http.ResponseWriter, _ *http.Request) {
+	status := http.StatusForbidden
+	if !errors.Is(err, ErrCSRFTokenMissing) && !errors.Is(err, ErrCSRFTokenInvalid) && !errors.Is(err, ErrCSRFOriginMismatch) {
+		status = http.StatusInternalServerError
+	}
+	http.Error(writer, http.StatusText(status), status)
+}
+
+// WithCSRFSession will configure Session option
+func WithCSRFSession() CSRFOption {
+	return func(config *CSRFConfig) {
+		config.Session = true
+	}
+}
+
+// WithCSRFCookie will configure CookieName, CookiePath and CookieDomain options
+func WithCSRFCookie(name string, path string, domain string) CSRFOption {
+	return func(config *CSRFConfig) {
+		config.CookieName = name
+		config.CookiePath = path
+		config.CookieDomain = domain
+	}
+}
+
+// WithCSRFCookieSecure will configure CookieSecure option
+func WithCSRFCookieSecure(secure bool) CSRFOption {
+	return func(config *CSRFConfig) {
+		config.CookieSecure = secure
+	}
+}
+
+// WithCSRFHeaderName will configure HeaderName option
+func WithCSRFHeaderName(headerName string) CSRFOption {
+	return func(config *CSRFConfig) {
+		config.HeaderName = headerName
+	}
+}
+
+// WithCSRFFormField will configure FormField option
+func WithCSRFFormField(formField string) CSRFOption {
+	return func(config *CSRFConfig) {
+		config.FormField = formField
+	}
+}
+
+// WithTrustedOrigins will configure TrustedOrigins option
+func WithTrustedOrigins(origins ...string) CSRFOption {
+	return func(config *CSRFConfig) {
+		config.TrustedOrigins = origins
+	}
+}
+
+// WithCSRFExempt will configure Exempt option
+func WithCSRFExempt(condition skip.Condition) CSRFOption {
+	return func(config *CSRFConfig) {
+		config.Exempt = condition
+	}
+}
+
+// WithCSRFErrorCallback will configure ErrorCallback option
+func WithCSRFErrorCallback(callback CSRFErrorCallback) CSRFOption {
+	return func(config *CSRFConfig) {
+		config.ErrorCallback = callback
+	}
+}
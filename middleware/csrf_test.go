@@ -0,0 +1,156 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/middleware"
+	"github.com/gol4ng/httpware/v4/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func csrfHandler(options ...middleware.CSRFOption) http.Handler {
+	return middleware.CSRF(options...)(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		_, _ = writer.Write([]byte(middleware.CSRFToken(req)))
+	}))
+}
+
+func TestCSRF(t *testing.T) {
+	handler := csrfHandler(middleware.WithTrustedOrigins("https://trusted.com"))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://fake-addr/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	cookies := recorder.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "csrf_token", cookies[0].Name)
+	token := recorder.Body.String()
+	assert.NotEmpty(t, token)
+
+	tests := []struct {
+		header         http.Header
+		form           url.Values
+		cookie         *http.Cookie
+		expectedStatus int
+	}{
+		{header: http.Header{"X-Csrf-Token": {token}}, cookie: cookies[0], expectedStatus: http.StatusOK},
+		// the raw cookie value is accepted
+		{header: http.Header{"X-Csrf-Token": {cookies[0].Value}}, cookie: cookies[0], expectedStatus: http.StatusOK},
+		{form: url.Values{"csrf_token": {token}}, cookie: cookies[0], expectedStatus: http.StatusOK},
+		{header: http.Header{"X-Csrf-Token": {token}, "Origin": {"http://fake-addr"}}, cookie: cookies[0], expectedStatus: http.StatusOK},
+		{header: http.Header{"X-Csrf-Token": {token}, "Origin": {"https://trusted.com"}}, cookie: cookies[0], expectedStatus: http.StatusOK},
+		{header: http.Header{"X-Csrf-Token": {token}, "Referer": {"http://fake-addr/form"}}, cookie: cookies[0], expectedStatus: http.StatusOK},
+		{header: http.Header{"X-Csrf-Token": {token}, "Origin": {"https://evil.com"}}, cookie: cookies[0], expectedStatus: http.StatusForbidden},
+		{header: http.Header{"X-Csrf-Token": {token}, "Referer": {"https://evil.com/form"}}, cookie: cookies[0], expectedStatus: http.StatusForbidden},
+		{cookie: cookies[0], expectedStatus: http.StatusForbidden},
+		{header: http.Header{"X-Csrf-Token": {"forged"}}, cookie: cookies[0], expectedStatus: http.StatusForbidden},
+		{header: http.Header{"X-Csrf-Token": {token}}, expectedStatus: http.StatusForbidden},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "http://fake-addr/", strings.NewReader(tt.form.Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			for name, values := range tt.header {
+				request.Header[name] = values
+			}
+			if tt.cookie != nil {
+				request.AddCookie(tt.cookie)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestCSRF_Exempt(t *testing.T) {
+	handler := csrfHandler(middleware.WithCSRFExempt(func(req *http.Request) bool {
+		return req.URL.Path == "/webhook"
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "http://fake-addr/webhook", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "http://fake-addr/form", nil))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestCSRF_Session(t *testing.T) {
+	codec, err := session.NewCodec(session.Keys{Hash: []byte("my_hash_key")})
+	assert.NoError(t, err)
+	handler := middleware.Session(session.NewManager(session.NewMemoryStore(), codec))(csrfHandler(middleware.WithCSRFSession()))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://fake-addr/", nil))
+	cookies := recorder.Result().Cookies()
+	// only the session cookie is set
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "session", cookies[0].Name)
+	token := recorder.Body.String()
+
+	request := httptest.NewRequest(http.MethodPost, "http://fake-addr/", nil)
+	request.Header.Set("X-CSRF-Token", token)
+	request.AddCookie(cookies[0])
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// the token of another session is rejected
+	request = httptest.NewRequest(http.MethodPost, "http://fake-addr/", nil)
+	request.Header.Set("X-CSRF-Token", token)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestCSRF_HTTPSWithoutOrigin(t *testing.T) {
+	var callbackErr error
+	handler := csrfHandler(middleware.WithCSRFErrorCallback(func(err error, writer http.ResponseWriter, _ *http.Request) {
+		callbackErr = err
+		writer.WriteHeader(http.StatusForbidden)
+	}))
+	request := httptest.NewRequest(http.MethodPost, "https://fake-addr/", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, middleware.ErrCSRFOriginMismatch, callbackErr)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	// no token is issued to a rejected request
+	assert.Empty(t, recorder.Result().Cookies())
+}
+
+func TestCSRF_OriginScheme(t *testing.T) {
+	handler := csrfHandler()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "https://fake-addr/", nil))
+	cookie := recorder.Result().Cookies()[0]
+	token := recorder.Body.String()
+
+	tests := []struct {
+		target         string
+		origin         string
+		expectedStatus int
+	}{
+		{target: "https://fake-addr/", origin: "https://fake-addr", expectedStatus: http.StatusOK},
+		{target: "https://fake-addr/", origin: "http://fake-addr", expectedStatus: http.StatusForbidden},
+		{target: "http://fake-addr/", origin: "http://fake-addr", expectedStatus: http.StatusOK},
+		{target: "http://fake-addr/", origin: "https://fake-addr", expectedStatus: http.StatusForbidden},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			request.Header.Set("Origin", tt.origin)
+			request.Header.Set("X-CSRF-Token", token)
+			request.AddCookie(cookie)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+		})
+	}
+}
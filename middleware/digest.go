@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gol4ng/httpware/v4/auth"
+)
+
+// DigestCredentialFinder returns the Authorization header credential, the auth.DigestCredential gets the request method and URI
+func DigestCredentialFinder(request *http.Request) auth.Credential {
+	credential := auth.ParseFromHeader(request)
+	if digest, ok := credential.(auth.DigestCredential); ok {
+		digest.Method = request.Method
+		digest.RequestURI = request.RequestURI
+		if digest.RequestURI == "" {
+			digest.RequestURI = request.URL.RequestURI()
+		}
+		return digest
+	}
+	return credential
+}
@@ -0,0 +1,30 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigestCredentialFinder(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "http://fake-addr", nil)
+	assert.Nil(t, middleware.DigestCredentialFinder(request))
+
+	request.Header.Set(auth.AuthorizationHeader, `Digest username="foo", nonce="my_nonce", response="my_response", uri="/"`)
+	assert.Equal(t, auth.DigestCredential{
+		Username:   "foo",
+		Nonce:      "my_nonce",
+		Response:   "my_response",
+		URI:        "/",
+		Algorithm:  auth.DigestMD5,
+		Method:     http.MethodPost,
+		RequestURI: "http://fake-addr",
+	}, middleware.DigestCredentialFinder(request))
+
+	request.Header.Set(auth.AuthorizationHeader, "Bearer my_token")
+	assert.Equal(t, auth.BearerToken("my_token"), middleware.DigestCredentialFinder(request))
+}
@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gol4ng/httpware/v4"
+)
+
+var (
+	// ErrLoadShedQueueFull is given to the error callback when the request cannot be queued
+	ErrLoadShedQueueFull = errors.New("server overloaded: queue full")
+	// ErrLoadShedQueueTimeout is given to the error callback when the request waited too long in the queue
+	ErrLoadShedQueueTimeout = errors.New("server overloaded: queue timeout")
+)
+
+// LoadShed middleware limits the number of concurrent requests, extra requests wait in a priority queue
+// the queue uses CoDel (controlled delay): when the queueing delay stayed above TargetDelay for a whole Interval
+// the queue is considered overloaded, waiting requests are then dropped after TargetDelay instead of Interval
+// and served in LIFO order (adaptive LIFO) in order to serve the fresh requests first
+// dropped requests never reach the next handler
+func LoadShed(options ...LoadShedOption) httpware.Middleware {
+	config := NewLoadShedConfig(options...)
+	shedder := &loadShedder{
+		config:        config,
+		intervalStart: time.Now(),
+		minDelay:      -1,
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			if err := shedder.acquire(req); err != nil {
+				config.ErrorCallback(err, writer, req)
+				return
+			}
+			defer shedder.release()
+			next.ServeHTTP(writer, req)
+		})
+	}
+}
+
+type loadShedWaiter struct {
+	priority   int
+	enqueuedAt time.Time
+	// closed when the waiter is granted or evicted
+	done    chan struct{}
+	granted bool
+}
+
+type loadShedder struct {
+	config   *LoadShedConfig
+	mutex    sync.Mutex
+	inflight int
+	queue    []*loadShedWaiter
+
+	intervalStart time.Time
+	// minimum queueing delay observed during the current interval, -1 when nothing was observed
+	minDelay   time.Duration
+	overloaded bool
+}
+
+func (l *loadShedder) acquire(req *http.Request) error {
+	now := time.Now()
+	l.mutex.Lock()
+	if l.inflight < l.config.MaxConcurrency && len(l.queue) == 0 {
+		l.inflight++
+		l.observe(0, now)
+		l.mutex.Unlock()
+		return nil
+	}
+
+	waiter := &loadShedWaiter{
+		priority:   l.config.Priority(req),
+		enqueuedAt: now,
+		done:       make(chan struct{}),
+	}
+	if len(l.queue) >= l.config.QueueSize {
+		i := l.lowestPriority()
+		if i < 0 || l.queue[i].priority >= waiter.priority {
+			l.mutex.Unlock()
+			return ErrLoadShedQueueFull
+		}
+		// evict a less important request in favor of this one
+		close(l.remove(i).done)
+	}
+	l.queue = append(l.queue, waiter)
+	timeout := l.config.Interval
+	if l.overloaded {
+		timeout = l.config.TargetDelay
+	}
+	l.mutex.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-waiter.done:
+	case <-timer.C:
+		err = ErrLoadShedQueueTimeout
+	case <-req.Context().Done():
+		err = req.Context().Err()
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if waiter.granted {
+		return nil
+	}
+	if err == nil {
+		return ErrLoadShedQueueFull
+	}
+	for i, w := range l.queue {
+		if w == waiter {
+			l.remove(i)
+			break
+		}
+	}
+	l.observe(time.Since(waiter.enqueuedAt), time.Now())
+	return err
+}
+
+// release gives the slot to the next waiting request or frees it
+func (l *loadShedder) release() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if len(l.queue) == 0 {
+		l.inflight--
+		return
+	}
+
+	next := 0
+	for i, w := range l.queue {
+		if w.priority > l.queue[next].priority || (l.overloaded && w.priority == l.queue[next].priority) {
+			next = i
+		}
+	}
+	waiter := l.remove(next)
+	now := time.Now()
+	l.observe(now.Sub(waiter.enqueuedAt), now)
+	waiter.granted = true
+	close(waiter.done)
+}
+
+// lowestPriority returns the index of the most recent request with the lowest priority
+func (l *loadShedder) lowestPriority() int {
+	lowest := -1
+	for i, w := range l.queue {
+		if lowest < 0 || w.priority <= l.queue[lowest].priority {
+			lowest = i
+		}
+	}
+	return lowest
+}
+
+func (l *loadShedder) remove(i int) *loadShedWaiter {
+	waiter := l.queue[i]
+	l.queue = append(l.queue[:i], l.queue[i+1:]...)
+	return waiter
+}
+
+// observe records a queueing delay, at the end of each interval the queue is flagged as overloaded
+// when even the shortest delay was above the target delay
+func (l *loadShedder) observe(delay time.Duration, now time.Time) {
+	if l.minDelay < 0 || delay < l.minDelay {
+		l.minDelay = delay
+	}
+	if now.Sub(l.intervalStart) >= l.config.Interval {
+		l.overloaded = l.minDelay > l.config.TargetDelay
+		l.minDelay = -1
+		l.intervalStart = now
+	}
+}
+
+type LoadShedErrorCallback func(err error, writer http.ResponseWriter, req *http.Request)
+
+// LoadShedOption defines a load shed middleware configuration option
+type LoadShedOption func(*LoadShedConfig)
+
+type LoadShedConfig struct {
+	// MaxConcurrency is the number of requests handled at the same time
+	MaxConcurrency int
+	// QueueSize is the number of requests that can wait for a slot
+	QueueSize int
+	// TargetDelay is the acceptable queueing delay (CoDel target)
+	TargetDelay time.Duration
+	// Interval is the CoDel sliding window and the queue timeout when the queue is not overloaded
+	Interval time.Duration
+	// Priority returns the request priority, higher priority requests are served first and evicted last
+	Priority func(*http.Request) int
+	// RetryAfter is sent to the client when the request is shed
+	RetryAfter    time.Duration
+	ErrorCallback LoadShedErrorCallback
+}
+
+func (c *LoadShedConfig) apply(options ...LoadShedOption) *LoadShedConfig {
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// NewLoadShedConfig returns a new load shed configuration with all options applied
+func NewLoadShedConfig(options ...LoadShedOption) *LoadShedConfig {
+	config := &LoadShedConfig{
+		MaxConcurrency: 100,
+		QueueSize:      100,
+		TargetDelay:    5 * time.Millisecond,
+		Interval:       100 * time.Millisecond,
+		Priority:       func(_ *http.Request) int { return 0 },
+		RetryAfter:     time.Second,
+	}
+	config.ErrorCallback = DefaultLoadShedErrorCallback(config)
+	return config.apply(options...)
+}
+
+// DefaultLoadShedErrorCallback responds 503 with the configured Retry-After header
+func DefaultLoadShedErrorCallback(config *LoadShedConfig) LoadShedErrorCallback {
+	return func(err error, writer http.ResponseWriter, _ *http.Request) {
+		if config.RetryAfter > 0 {
+			writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(config.RetryAfter.Seconds()))))
+		}
+		http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+	}
+}
+
+// WithLoadShedMaxConcurrency will configure MaxConcurrency option
+func WithLoadShedMaxConcurrency(maxConcurrency int) LoadShedOption {
+	return func(config *LoadShedConfig) {
+		config.MaxConcurrency = maxConcurrency
+	}
+}
+
+// WithLoadShedQueueSize will configure QueueSize option
+func WithLoadShedQueueSize(queueSize int) LoadShedOption {
+	return func(config *LoadShedConfig) {
+		config.QueueSize = queueSize
+	}
+}
+
+// WithLoadShedCoDel will configure TargetDelay and Interval options
+func WithLoadShedCoDel(targetDelay time.Duration, interval time.Duration) LoadShedOption {
+	return func(config *LoadShedConfig) {
+		config.TargetDelay = targetDelay
+		config.Interval = interval
+	}
+}
+
+// WithLoadShedPriority will configure Priority option
+func WithLoadShedPriority(priority func(*http.Request) int) LoadShedOption {
+	return func(config *LoadShedConfig) {
+		config.Priority = priority
+	}
+}
+
+// WithLoadShedRetryAfter will configure RetryAfter option
+func WithLoadShedRetryAfter(retryAfter time.Duration) LoadShedOption {
+	return func(config *LoadShedConfig) {
+		config.RetryAfter = retryAfter
+	}
+}
+
+// WithLoadShedErrorCallback will configure ErrorCallback option
+func WithLoadShedErrorCallback(callback LoadShedErrorCallback) LoadShedOption {
+	return func(config *LoadShedConfig) {
+		config.ErrorCallback = callback
+	}
+}
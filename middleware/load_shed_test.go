@@ -0,0 +1,160 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadShed_QueueFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := middleware.LoadShed(
+		middleware.WithLoadShedMaxConcurrency(1),
+		middleware.WithLoadShedQueueSize(0),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	firstRecorder := httptest.NewRecorder()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(firstRecorder, httptest.NewRequest(http.MethodGet, "http://fake-addr", nil))
+	}()
+	<-started
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://fake-addr", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+	assert.Equal(t, "server overloaded: queue full\n", recorder.Body.String())
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, firstRecorder.Code)
+}
+
+func TestLoadShed_Priority(t *testing.T) {
+	release := make(chan struct{})
+	var mutex sync.Mutex
+	var served []string
+	handler := middleware.LoadShed(
+		middleware.WithLoadShedMaxConcurrency(1),
+		middleware.WithLoadShedQueueSize(2),
+		middleware.WithLoadShedCoDel(time.Second, time.Second),
+		middleware.WithLoadShedPriority(func(req *http.Request) int {
+			if req.URL.Path == "/health" {
+				return 1
+			}
+			return 0
+		}),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		mutex.Lock()
+		served = append(served, req.URL.Path)
+		mutex.Unlock()
+		if req.URL.Path == "/first" {
+			<-release
+		}
+	}))
+
+	var wg sync.WaitGroup
+	for _, path := range []string{"/first", "/second", "/health"} {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://fake-addr"+path, nil))
+		}(path)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []string{"/first", "/health", "/second"}, served)
+}
+
+func TestLoadShed_EvictLowerPriority(t *testing.T) {
+	release := make(chan struct{})
+	handler := middleware.LoadShed(
+		middleware.WithLoadShedMaxConcurrency(1),
+		middleware.WithLoadShedQueueSize(1),
+		middleware.WithLoadShedCoDel(time.Second, time.Second),
+		middleware.WithLoadShedPriority(func(req *http.Request) int {
+			if req.URL.Path == "/health" {
+				return 1
+			}
+			return 0
+		}),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/first" {
+			<-release
+		}
+	}))
+
+	recorders := map[string]*httptest.ResponseRecorder{}
+	var wg sync.WaitGroup
+	for _, path := range []string{"/first", "/second", "/health"} {
+		recorder := httptest.NewRecorder()
+		recorders[path] = recorder
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://fake-addr"+path, nil))
+		}(path)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, recorders["/first"].Code)
+	assert.Equal(t, http.StatusServiceUnavailable, recorders["/second"].Code)
+	assert.Equal(t, http.StatusOK, recorders["/health"].Code)
+}
+
+func TestLoadShed_CoDel(t *testing.T) {
+	release := make(chan struct{}, 1)
+	var errs []error
+	handler := middleware.LoadShed(
+		middleware.WithLoadShedMaxConcurrency(1),
+		middleware.WithLoadShedQueueSize(10),
+		middleware.WithLoadShedCoDel(5*time.Millisecond, 50*time.Millisecond),
+		middleware.WithLoadShedErrorCallback(func(err error, writer http.ResponseWriter, _ *http.Request) {
+			errs = append(errs, err)
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/slow" {
+			<-release
+		}
+	}))
+	serve := func(path string) int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://fake-addr"+path, nil))
+		return recorder.Code
+	}
+
+	go serve("/slow")
+	time.Sleep(10 * time.Millisecond)
+
+	// not overloaded, the queued requests wait up to the interval
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		assert.Equal(t, http.StatusServiceUnavailable, serve("/"))
+		assert.True(t, time.Since(start) >= 50*time.Millisecond)
+	}
+	assert.Equal(t, []error{middleware.ErrLoadShedQueueTimeout, middleware.ErrLoadShedQueueTimeout}, errs)
+
+	// a whole interval was spent above the target delay, the queue is now overloaded
+	start := time.Now()
+	assert.Equal(t, http.StatusServiceUnavailable, serve("/"))
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+
+	release <- struct{}{}
+}
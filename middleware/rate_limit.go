@@ -1,7 +1,10 @@
 package middleware
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gol4ng/httpware/v4"
 	"github.com/gol4ng/httpware/v4/rate_limit"
@@ -12,14 +15,15 @@ func RateLimit(limiter rate_limit.RateLimiter, options ...RateLimitOption) httpw
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
-			if err := limiter.Allow(req); err != nil {
+			cost := config.Cost(req)
+			if err := rate_limit.AllowN(limiter, req, cost); err != nil {
 				if !config.ErrorCallback(err, writer, req) {
 					return
 				}
 			}
 
-			limiter.Inc(req)
-			defer limiter.Dec(req)
+			rate_limit.IncN(limiter, req, cost)
+			defer rate_limit.DecN(limiter, req, cost)
 			next.ServeHTTP(writer, req)
 		})
 	}
@@ -31,6 +35,8 @@ type RateLimitErrorCallback func(err error, writer http.ResponseWriter, req *htt
 
 type RateLimitConfig struct {
 	ErrorCallback RateLimitErrorCallback
+	// Cost returns the number of tokens consumed by the request
+	Cost rate_limit.CostFunc
 }
 
 func (c *RateLimitConfig) apply(options ...RateLimitOption) *RateLimitConfig {
@@ -43,11 +49,17 @@ func (c *RateLimitConfig) apply(options ...RateLimitOption) *RateLimitConfig {
 func NewRateLimitConfig(options ...RateLimitOption) *RateLimitConfig {
 	config := &RateLimitConfig{
 		ErrorCallback: DefaultRateLimitErrorCallback,
+		Cost:          rate_limit.DefaultCost,
 	}
 	return config.apply(options...)
 }
 
+// DefaultRateLimitErrorCallback responds 429 with a Retry-After header when the error is a rate_limit.LimitError
 func DefaultRateLimitErrorCallback(err error, writer http.ResponseWriter, _ *http.Request) bool {
+	limitErr := &rate_limit.LimitError{}
+	if errors.As(err, &limitErr) && limitErr.RetryAfter > 0 {
+		writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))))
+	}
 	http.Error(writer, err.Error(), http.StatusTooManyRequests)
 	return false
 }
@@ -57,3 +69,10 @@ func WithRateLimitErrorCallback(callback RateLimitErrorCallback) RateLimitOption
 		config.ErrorCallback = callback
 	}
 }
+
+// WithRateLimitCost will configure Cost option
+func WithRateLimitCost(cost rate_limit.CostFunc) RateLimitOption {
+	return func(config *RateLimitConfig) {
+		config.Cost = cost
+	}
+}
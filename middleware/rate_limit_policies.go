@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gol4ng/httpware/v4"
+	"github.com/gol4ng/httpware/v4/rate_limit"
+)
+
+// RateLimitPolicy applies the Limiter to the requests selected by the Matcher
+// Options configure the RateLimit middleware of the policy (error callback, cost...)
+type RateLimitPolicy struct {
+	Matcher rate_limit.Matcher
+	Limiter rate_limit.RateLimiter
+	Options []RateLimitOption
+}
+
+// NewRateLimitPolicy creates a RateLimitPolicy, a nil matcher matches every request
+func NewRateLimitPolicy(matcher rate_limit.Matcher, limiter rate_limit.RateLimiter, options ...RateLimitOption) RateLimitPolicy {
+	return RateLimitPolicy{
+		Matcher: matcher,
+		Limiter: limiter,
+		Options: options,
+	}
+}
+
+// RateLimitPolicies middleware rate limits the request with the first policy that matches it
+// policies are evaluated in order and the evaluation stops at the first match
+// a request that doesn't match any policy is not rate limited
+func RateLimitPolicies(policies ...RateLimitPolicy) httpware.Middleware {
+	return func(next http.Handler) http.Handler {
+		handlers := make([]http.Handler, len(policies))
+		for i, policy := range policies {
+			handlers[i] = RateLimit(policy.Limiter, policy.Options...)(next)
+		}
+		return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			for i, policy := range policies {
+				if policy.Matcher == nil || policy.Matcher(req) {
+					handlers[i].ServeHTTP(writer, req)
+					return
+				}
+			}
+			next.ServeHTTP(writer, req)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/middleware"
+	"github.com/gol4ng/httpware/v4/rate_limit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitPolicies(t *testing.T) {
+	exportLimiter := rate_limit.NewMultiWindow(rate_limit.Window{Duration: time.Minute, Limit: 1})
+	defaultLimiter := rate_limit.NewMultiWindow(rate_limit.Window{Duration: time.Minute, Limit: 2})
+
+	matcherCalls := 0
+	handler := middleware.RateLimitPolicies(
+		middleware.NewRateLimitPolicy(rate_limit.MatchPath("/health"), rate_limit.NewMultiWindow()),
+		middleware.NewRateLimitPolicy(
+			rate_limit.MatchAll(rate_limit.MatchMethod(http.MethodPost), rate_limit.MatchPath("/export")),
+			exportLimiter,
+			middleware.WithRateLimitErrorCallback(func(err error, writer http.ResponseWriter, _ *http.Request) bool {
+				http.Error(writer, "too many exports", http.StatusServiceUnavailable)
+				return false
+			}),
+		),
+		middleware.NewRateLimitPolicy(func(req *http.Request) bool {
+			matcherCalls++
+			return req.Header.Get("X-Tenant") != ""
+		}, defaultLimiter),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {}))
+
+	serve := func(method string, path string, tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://fake-addr"+path, nil)
+		if tenant != "" {
+			req.Header.Set("X-Tenant", tenant)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/health", "").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/health", "foo").Code)
+	assert.Equal(t, 0, matcherCalls)
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/export", "foo").Code)
+	recorder := serve(http.MethodPost, "/export", "foo")
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "too many exports\n", recorder.Body.String())
+	assert.Equal(t, 0, matcherCalls)
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/export", "foo").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/users", "foo").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(http.MethodGet, "/users", "foo").Code)
+	assert.Equal(t, 3, matcherCalls)
+
+	// no policy matches
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/users", "").Code)
+}
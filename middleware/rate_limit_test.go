@@ -43,6 +43,56 @@ func TestRateLimit(t *testing.T) {
 	rateLimiterMock.AssertExpectations(t)
 }
 
+func TestRateLimit_LimitError(t *testing.T) {
+	rateLimiterMock := &mocks.RateLimiter{}
+	rateLimiterMock.On("Allow", mock.AnythingOfType("*http.Request")).Return(&rate_limit.LimitError{
+		Limit:      10,
+		RetryAfter: 1500 * time.Millisecond,
+		Window:     time.Minute,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	responseWriter := httptest.NewRecorder()
+
+	var callbackErr error
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	middleware.RateLimit(rateLimiterMock, middleware.WithRateLimitErrorCallback(func(err error, writer http.ResponseWriter, req *http.Request) bool {
+		callbackErr = err
+		return middleware.DefaultRateLimitErrorCallback(err, writer, req)
+	}))(handler).ServeHTTP(responseWriter, req)
+
+	assert.True(t, errors.Is(callbackErr, rate_limit.ErrLimitReached))
+	assert.Equal(t, http.StatusTooManyRequests, responseWriter.Code)
+	assert.Equal(t, "2", responseWriter.Header().Get("Retry-After"))
+	assert.Equal(t, "request limit reached: 10 per 1m0s\n", responseWriter.Body.String())
+
+	rateLimiterMock.AssertExpectations(t)
+}
+
+func TestRateLimit_WithRateLimitCost(t *testing.T) {
+	limiter := rate_limit.NewMultiWindow(rate_limit.Window{Duration: time.Minute, Limit: 10})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	rateLimit := middleware.RateLimit(limiter, middleware.WithRateLimitCost(func(req *http.Request) int {
+		if req.URL.Path == "/export" {
+			return 8
+		}
+		return 1
+	}))(handler)
+
+	responseWriter := httptest.NewRecorder()
+	rateLimit.ServeHTTP(responseWriter, httptest.NewRequest(http.MethodGet, "http://fake-addr/export", nil))
+	assert.Equal(t, http.StatusOK, responseWriter.Code)
+
+	responseWriter = httptest.NewRecorder()
+	rateLimit.ServeHTTP(responseWriter, httptest.NewRequest(http.MethodGet, "http://fake-addr/export", nil))
+	assert.Equal(t, http.StatusTooManyRequests, responseWriter.Code)
+
+	responseWriter = httptest.NewRecorder()
+	rateLimit.ServeHTTP(responseWriter, httptest.NewRequest(http.MethodGet, "http://fake-addr/search", nil))
+	assert.Equal(t, http.StatusOK, responseWriter.Code)
+}
+
 // =====================================================================================================================
 // ========================================= EXAMPLES ==================================================================
 // =====================================================================================================================
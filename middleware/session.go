@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/gol4ng/httpware/v4"
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/session"
+)
+
+// Session middleware loads the request session in the context (see session.FromContext)
+// the session of a logged in user is also the request credential (see auth.CredentialFromContext)
+// the session is saved and its cookie is set before the response headers are written,
+// the modifications made after are not saved
+func Session(manager *session.Manager, options ...SessionOption) httpware.Middleware {
+	config := NewSessionConfig(options...)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			s := manager.Load(req)
+			ctx := session.ToContext(req.Context(), s)
+			if s.UserID != "" {
+				ctx = auth.CredentialToContext(ctx, s)
+			}
+			req = req.WithContext(ctx)
+
+			saved := false
+			save := func() {
+				if !saved {
+					saved = true
+					if err := manager.Save(writer, req, s); err != nil {
+						config.ErrorCallback(err, writer, req)
+					}
+				}
+			}
+			wrapped := httpsnoop.Wrap(writer, httpsnoop.Hooks{
+				WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+					return func(code int) {
+						save()
+						next(code)
+					}
+				},
+				Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+					return func(p []byte) (int, error) {
+						save()
+						return next(p)
+					}
+				},
+				ReadFrom: func(next httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+					return func(src io.Reader) (int64, error) {
+						save()
+						return next(src)
+					}
+				},
+				Flush: func(next httpsnoop.FlushFunc) httpsnoop.FlushFunc {
+					return func() {
+						save()
+						next()
+					}
+				},
+			})
+			next.ServeHTTP(wrapped, req)
+			save()
+		})
+	}
+}
+
+// SessionErrorCallback is called when the session cannot be saved, before the response headers are written
+type SessionErrorCallback func(err error, writer http.ResponseWriter, req *http.Request)
+
+// SessionOption defines a session middleware configuration option
+type SessionOption func(*SessionConfig)
+
+type SessionConfig struct {
+	ErrorCallback SessionErrorCallback
+}
+
+func (c *SessionConfig) apply(options ...SessionOption) *SessionConfig {
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// NewSessionConfig returns a new session configuration with all options applied
+func NewSessionConfig(options ...SessionOption) *SessionConfig {
+	config := &SessionConfig{
+		ErrorCallback: DefaultSessionErrorCallback,
+	}
+	return config.apply(options...)
+}
+
+// DefaultSessionErrorCallback ignores the error, the session is lost and the response is still sent
+func DefaultSessionErrorCallback(_ error, _ http.ResponseWriter, _ *http.Request) {}
+
+// WithSessionErrorCallback will configure ErrorCallback option
+func WithSessionErrorCallback(callback SessionErrorCallback) SessionOption {
+	return func(config *SessionConfig) {
+		config.ErrorCallback = callback
+	}
+}
@@ -0,0 +1,93 @@
+package middleware_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/middleware"
+	"github.com/gol4ng/httpware/v4/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	codec, err := session.NewCodec(session.Keys{Hash: []byte("my_hash_key")})
+	assert.NoError(t, err)
+	handler := middleware.Session(session.NewManager(session.NewMemoryStore(), codec))(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		s := session.FromContext(req.Context())
+		if req.URL.Path == "/login" {
+			s.Login("foo")
+		}
+		if credential, ok := auth.CredentialFromContext(req.Context()).(*session.Session); ok {
+			_, _ = writer.Write([]byte(credential.UserID))
+		}
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://fake-addr/login", nil))
+	assert.Equal(t, "", recorder.Body.String())
+	cookies := recorder.Result().Cookies()
+	assert.Len(t, cookies, 1)
+
+	request := httptest.NewRequest(http.MethodGet, "http://fake-addr/", nil)
+	request.AddCookie(cookies[0])
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, "foo", recorder.Body.String())
+	assert.Empty(t, recorder.Result().Cookies())
+}
+
+func TestSession_SavedBeforeWrite(t *testing.T) {
+	codec, err := session.NewCodec(session.Keys{Hash: []byte("my_hash_key")})
+	assert.NoError(t, err)
+	handler := middleware.Session(session.NewManager(session.CookieStore{}, codec))(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		session.FromContext(req.Context()).Set("my_key", "my_value")
+		writer.WriteHeader(http.StatusCreated)
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://fake-addr", nil))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Len(t, recorder.Result().Cookies(), 1)
+}
+
+func TestSession_SavedBeforeReadFrom(t *testing.T) {
+	codec, err := session.NewCodec(session.Keys{Hash: []byte("my_hash_key")})
+	assert.NoError(t, err)
+	server := httptest.NewServer(middleware.Session(session.NewManager(session.CookieStore{}, codec))(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		session.FromContext(req.Context()).Set("my_key", "my_value")
+		// the limit reader is not an io.WriterTo, io.Copy calls the ReadFrom of the response writer instead of Write
+		_, _ = io.Copy(writer, io.LimitReader(strings.NewReader(strings.Repeat("a", 10000)), 10000))
+	})))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Len(t, body, 10000)
+	assert.Len(t, resp.Cookies(), 1)
+}
+
+func TestSession_ErrorCallback(t *testing.T) {
+	codec, err := session.NewCodec(session.Keys{Hash: []byte("my_hash_key")})
+	assert.NoError(t, err)
+	var saveErr error
+	handler := middleware.Session(
+		session.NewManager(session.CookieStore{}, codec),
+		middleware.WithSessionErrorCallback(func(err error, writer http.ResponseWriter, req *http.Request) {
+			saveErr = err
+		}),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		session.FromContext(req.Context()).Set("my_key", strings.Repeat("a", 5000))
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://fake-addr", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Result().Cookies())
+	assert.Equal(t, session.ErrCookieTooLarge, saveErr)
+}
@@ -0,0 +1,317 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gol4ng/httpware/v4"
+	"github.com/gol4ng/httpware/v4/interceptor"
+)
+
+// DefaultSignatureTolerance is the replay window of the timestamped schemes given a zero tolerance
+// and the deduplication window of the GitHub delivery ids
+const DefaultSignatureTolerance = 5 * time.Minute
+
+var (
+	// ErrSignatureMissing is given to the error callback when the request is not signed
+	ErrSignatureMissing = errors.New("signature missing")
+	// ErrSignatureInvalid is given to the error callback when no signature matches the body
+	ErrSignatureInvalid = errors.New("signature invalid")
+	// ErrSignatureExpired is given to the error callback when the signature timestamp is outside the tolerance
+	ErrSignatureExpired = errors.New("signature expired")
+	// ErrSignatureReplayed is given to the error callback when the signature was already received
+	ErrSignatureReplayed = errors.New("signature replayed")
+	// ErrSignatureUnsignedTimestamp is given to the error callback when the scheme checks a timestamp without Payload to sign it
+	ErrSignatureUnsignedTimestamp = errors.New("signature scheme does not sign its timestamp")
+)
+
+// SignatureScheme describes how a webhook sender signs the raw body of its requests with HMAC
+type SignatureScheme struct {
+	// Secrets are the shared secrets, several secrets can be given during a rotation
+	Secrets [][]byte
+	Hash    func() hash.Hash
+	// Extract returns the timestamp and the encoded signatures of the request, the timestamp is empty when the scheme has none
+	Extract func(req *http.Request) (timestamp string, signatures []string)
+	// Payload returns the signed content
+	Payload func(timestamp string, body []byte) []byte
+	// Decode decodes a signature, hex by default
+	Decode func(signature string) ([]byte, error)
+	// Tolerance is the replay window: the timestamp must be within Tolerance of now and a signature is accepted once
+	// the Payload must sign the timestamp, the requests are rejected with ErrSignatureUnsignedTimestamp otherwise
+	// zero disables the timestamp check
+	Tolerance time.Duration
+	// DeliveryID returns the unique id of the request for the schemes without timestamp,
+	// the timestamp is not checked and a delivery id is accepted once within Tolerance
+	// the id is not signed: it drops the duplicated deliveries but it is not a replay protection
+	DeliveryID func(req *http.Request) string
+}
+
+// GitHubSignature verifies the "X-Hub-Signature-256: sha256=<hex>" header of GitHub webhooks
+// GitHub signs neither a timestamp nor the delivery id: this scheme has no replay protection,
+// a captured body and signature can be sent again forever with a new "X-GitHub-Delivery" id
+// the delivery ids are only deduplicated within DefaultSignatureTolerance to drop the redelivered events
+func GitHubSignature(secrets ...string) SignatureScheme {
+	return SignatureScheme{
+		Secrets: secretBytes(secrets),
+		Hash:    sha256.New,
+		Extract: headerSignature("X-Hub-Signature-256", "sha256="),
+		DeliveryID: func(req *http.Request) string {
+			return req.Header.Get("X-GitHub-Delivery")
+		},
+		Tolerance: DefaultSignatureTolerance,
+	}
+}
+
+// StripeSignature verifies the "Stripe-Signature: t=<timestamp>,v1=<hex>" header of Stripe webhooks
+// a zero tolerance uses DefaultSignatureTolerance
+func StripeSignature(tolerance time.Duration, secrets ...string) SignatureScheme {
+	return SignatureScheme{
+		Secrets: secretBytes(secrets),
+		Hash:    sha256.New,
+		Extract: func(req *http.Request) (timestamp string, signatures []string) {
+			for _, part := range strings.Split(req.Header.Get("Stripe-Signature"), ",") {
+				name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+				switch name {
+				case "t":
+					timestamp = value
+				case "v1":
+					signatures = append(signatures, value)
+				}
+			}
+			return timestamp, signatures
+		},
+		Payload: func(timestamp string, body []byte) []byte {
+			return append([]byte(timestamp+"."), body...)
+		},
+		Tolerance: defaultTolerance(tolerance),
+	}
+}
+
+// SlackSignature verifies the "X-Slack-Signature: v0=<hex>" and "X-Slack-Request-Timestamp" headers of Slack requests
+// a zero tolerance uses DefaultSignatureTolerance
+func SlackSignature(tolerance time.Duration, secrets ...string) SignatureScheme {
+	signature := headerSignature("X-Slack-Signature", "v0=")
+	return SignatureScheme{
+		Secrets: secretBytes(secrets),
+		Hash:    sha256.New,
+		Extract: func(req *http.Request) (string, []string) {
+			_, signatures := signature(req)
+			return req.Header.Get("X-Slack-Request-Timestamp"), signatures
+		},
+		Payload: func(timestamp string, body []byte) []byte {
+			return append([]byte("v0:"+timestamp+":"), body...)
+		},
+		Tolerance: defaultTolerance(tolerance),
+	}
+}
+
+// HMACSignature verifies a hex HMAC-SHA256 of the body sent in the given header, the prefix (ie: "sha256=") is optional
+// it has no replay protection, the returned scheme can be customized (timestamp, hash, encoding...)
+// a Tolerance requires an Extract returning the timestamp and a Payload signing it
+func HMACSignature(header string, prefix string, secrets ...string) SignatureScheme {
+	return SignatureScheme{
+		Secrets: secretBytes(secrets),
+		Hash:    sha256.New,
+		Extract: headerSignature(header, prefix),
+	}
+}
+
+// VerifySignature middleware checks the HMAC signature of the raw request body before calling the next handler
+// the body is buffered (see interceptor.NewCopyReadCloser) so the next handler can read it again
+// the signatures are compared in constant time
+func VerifySignature(scheme SignatureScheme, options ...SignatureOption) httpware.Middleware {
+	config := NewSignatureConfig(options...)
+	verifier := &signatureVerifier{scheme: scheme, seen: map[string]time.Time{}, lastSweep: time.Now()}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			var body []byte
+			if req.Body != nil && req.Body != http.NoBody {
+				copyBody := interceptor.NewCopyReadCloser(http.MaxBytesReader(writer, req.Body, config.MaxBodySize))
+				var err error
+				// reading until EOF makes the copy read closer serve its buffered copy to the next handler
+				if body, err = ioutil.ReadAll(copyBody); err != nil {
+					config.ErrorCallback(err, writer, req)
+					return
+				}
+				req.Body = copyBody
+			}
+			if err := verifier.verify(req, body); err != nil {
+				config.ErrorCallback(err, writer, req)
+				return
+			}
+			next.ServeHTTP(writer, req)
+		})
+	}
+}
+
+type signatureVerifier struct {
+	scheme SignatureScheme
+	mutex  sync.Mutex
+	// seen signatures (or delivery ids) with their expiration, used to reject the replays within the tolerance
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+func (v *signatureVerifier) verify(req *http.Request, body []byte) error {
+	timestamp, signatures := v.scheme.Extract(req)
+	if len(signatures) == 0 {
+		return ErrSignatureMissing
+	}
+	now := time.Now()
+	var signedAt time.Time
+	if v.scheme.Tolerance > 0 && v.scheme.DeliveryID == nil {
+		// a timestamp that is not signed can be changed, it would not protect against the replays
+		if v.scheme.Payload == nil {
+			return ErrSignatureUnsignedTimestamp
+		}
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return ErrSignatureInvalid
+		}
+		signedAt = time.Unix(seconds, 0)
+		if delta := now.Sub(signedAt); delta > v.scheme.Tolerance || delta < -v.scheme.Tolerance {
+			return ErrSignatureExpired
+		}
+	}
+
+	payload := body
+	if v.scheme.Payload != nil {
+		payload = v.scheme.Payload(timestamp, body)
+	}
+	decode := v.scheme.Decode
+	if decode == nil {
+		decode = hex.DecodeString
+	}
+	for _, secret := range v.scheme.Secrets {
+		mac := hmac.New(v.scheme.Hash, secret)
+		mac.Write(payload)
+		expected := mac.Sum(nil)
+		for _, signature := range signatures {
+			decoded, err := decode(signature)
+			if err != nil || !hmac.Equal(decoded, expected) {
+				continue
+			}
+			if v.scheme.Tolerance <= 0 {
+				return nil
+			}
+			key, expiration := string(expected), signedAt.Add(v.scheme.Tolerance)
+			if v.scheme.DeliveryID != nil {
+				id := v.scheme.DeliveryID(req)
+				if id == "" {
+					return ErrSignatureInvalid
+				}
+				key, expiration = "delivery:"+id, now.Add(v.scheme.Tolerance)
+			}
+			if !v.remember(key, expiration, now) {
+				return ErrSignatureReplayed
+			}
+			return nil
+		}
+	}
+	return ErrSignatureInvalid
+}
+
+// remember records the signature until its expiration, it returns false when the signature was already seen
+// the expired signatures are removed at most once per tolerance
+func (v *signatureVerifier) remember(signature string, expiration time.Time, now time.Time) bool {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if now.Sub(v.lastSweep) >= v.scheme.Tolerance {
+		v.lastSweep = now
+		for s, exp := range v.seen {
+			if now.After(exp) {
+				delete(v.seen, s)
+			}
+		}
+	}
+	if exp, ok := v.seen[signature]; ok && !now.After(exp) {
+		return false
+	}
+	v.seen[signature] = expiration
+	return true
+}
+
+func defaultTolerance(tolerance time.Duration) time.Duration {
+	if tolerance <= 0 {
+		return DefaultSignatureTolerance
+	}
+	return tolerance
+}
+
+func headerSignature(header string, prefix string) func(*http.Request) (string, []string) {
+	return func(req *http.Request) (string, []string) {
+		signature := strings.TrimPrefix(req.Header.Get(header), prefix)
+		if signature == "" {
+			return "", nil
+		}
+		return "", []string{signature}
+	}
+}
+
+func secretBytes(secrets []string) [][]byte {
+	result := make([][]byte, 0, len(secrets))
+	for _, secret := range secrets {
+		result = append(result, []byte(secret))
+	}
+	return result
+}
+
+type SignatureErrorCallback func(err error, writer http.ResponseWriter, req *http.Request)
+
+// SignatureOption defines a signature middleware configuration option
+type SignatureOption func(*SignatureConfig)
+
+type SignatureConfig struct {
+	// MaxBodySize is the maximum size of the verified body
+	MaxBodySize   int64
+	ErrorCallback SignatureErrorCallback
+}
+
+func (c *SignatureConfig) apply(options ...SignatureOption) *SignatureConfig {
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// NewSignatureConfig returns a new signature configuration with all options applied
+func NewSignatureConfig(options ...SignatureOption) *SignatureConfig {
+	config := &SignatureConfig{
+		MaxBodySize:   1 << 20,
+		ErrorCallback: DefaultSignatureErrorCallback,
+	}
+	return config.apply(options...)
+}
+
+// DefaultSignatureErrorCallback responds 401, or 413 when the body is too large
+func DefaultSignatureErrorCallback(err error, writer http.ResponseWriter, _ *http.Request) {
+	status := http.StatusUnauthorized
+	maxBytesErr := &http.MaxBytesError{}
+	if errors.As(err, &maxBytesErr) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	http.Error(writer, http.StatusText(status), status)
+}
+
+// WithMaxBodySize will configure MaxBodySize option
+func WithMaxBodySize(maxBodySize int64) SignatureOption {
+	return func(config *SignatureConfig) {
+		config.MaxBodySize = maxBodySize
+	}
+}
+
+// WithSignatureErrorCallback will configure ErrorCallback option
+func WithSignatureErrorCallback(callback SignatureErrorCallback) SignatureOption {
+	return func(config *SignatureConfig) {
+		config.ErrorCallback = callback
+	}
+}
@@ -0,0 +1,195 @@
+package middleware_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func hmacHex(secret string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := `{"event":"my_event"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		scheme         middleware.SignatureScheme
+		header         http.Header
+		expectedStatus int
+	}{
+		{
+			scheme:         middleware.GitHubSignature("my_secret"),
+			header:         http.Header{"X-Hub-Signature-256": {"sha256=" + hmacHex("my_secret", body)}, "X-Github-Delivery": {"my_delivery"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			// secret rotation
+			scheme:         middleware.GitHubSignature("my_new_secret", "my_secret"),
+			header:         http.Header{"X-Hub-Signature-256": {"sha256=" + hmacHex("my_secret", body)}, "X-Github-Delivery": {"my_delivery"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			scheme:         middleware.GitHubSignature("my_secret"),
+			header:         http.Header{"X-Hub-Signature-256": {"sha256=" + hmacHex("wrong", body)}, "X-Github-Delivery": {"my_delivery"}},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			scheme:         middleware.GitHubSignature("my_secret"),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			// the delivery id is required
+			scheme:         middleware.GitHubSignature("my_secret"),
+			header:         http.Header{"X-Hub-Signature-256": {"sha256=" + hmacHex("my_secret", body)}},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			scheme:         middleware.StripeSignature(5*time.Minute, "my_secret"),
+			header:         http.Header{"Stripe-Signature": {"t=" + now + ",v1=" + hmacHex("wrong", now+"."+body) + ",v1=" + hmacHex("my_secret", now+"."+body)}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			scheme:         middleware.StripeSignature(5*time.Minute, "my_secret"),
+			header:         http.Header{"Stripe-Signature": {"t=" + old + ",v1=" + hmacHex("my_secret", old+"."+body)}},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			// a zero tolerance uses the default tolerance
+			scheme:         middleware.StripeSignature(0, "my_secret"),
+			header:         http.Header{"Stripe-Signature": {"t=" + old + ",v1=" + hmacHex("my_secret", old+"."+body)}},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			scheme:         middleware.StripeSignature(5*time.Minute, "my_secret"),
+			header:         http.Header{"Stripe-Signature": {"v1=" + hmacHex("my_secret", "."+body)}},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			scheme:         middleware.SlackSignature(5*time.Minute, "my_secret"),
+			header:         http.Header{"X-Slack-Signature": {"v0=" + hmacHex("my_secret", "v0:"+now+":"+body)}, "X-Slack-Request-Timestamp": {now}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			scheme:         middleware.SlackSignature(5*time.Minute, "my_secret"),
+			header:         http.Header{"X-Slack-Signature": {"v0=" + hmacHex("my_secret", "v0:"+now+":"+body)}, "X-Slack-Request-Timestamp": {old}},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			scheme:         middleware.HMACSignature("X-Signature", "", "my_secret"),
+			header:         http.Header{"X-Signature": {hmacHex("my_secret", body)}},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			handler := middleware.VerifySignature(tt.scheme)(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+				// the body can still be read by the handler
+				b, err := ioutil.ReadAll(req.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, body, string(b))
+			}))
+			request := httptest.NewRequest(http.MethodPost, "http://fake-addr/webhook", strings.NewReader(body))
+			for name, values := range tt.header {
+				request.Header[name] = values
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestVerifySignature_Replay(t *testing.T) {
+	var errs []error
+	handler := middleware.VerifySignature(
+		middleware.StripeSignature(5*time.Minute, "my_secret"),
+		middleware.WithSignatureErrorCallback(func(err error, writer http.ResponseWriter, _ *http.Request) {
+			errs = append(errs, err)
+			writer.WriteHeader(http.StatusUnauthorized)
+		}),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {}))
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	signature := "t=" + now + ",v1=" + hmacHex("my_secret", now+".my_body")
+	for _, expectedStatus := range []int{http.StatusOK, http.StatusUnauthorized} {
+		request := httptest.NewRequest(http.MethodPost, "http://fake-addr/webhook", strings.NewReader("my_body"))
+		request.Header.Set("Stripe-Signature", signature)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, expectedStatus, recorder.Code)
+	}
+	assert.Equal(t, []error{middleware.ErrSignatureReplayed}, errs)
+}
+
+func TestVerifySignature_GitHubDelivery(t *testing.T) {
+	handler := middleware.VerifySignature(middleware.GitHubSignature("my_secret"))(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {}))
+
+	tests := []struct {
+		delivery       string
+		expectedStatus int
+	}{
+		{delivery: "my_delivery", expectedStatus: http.StatusOK},
+		{delivery: "my_delivery", expectedStatus: http.StatusUnauthorized},
+		{delivery: "my_other_delivery", expectedStatus: http.StatusOK},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "http://fake-addr/webhook", strings.NewReader("my_body"))
+			request.Header.Set("X-Hub-Signature-256", "sha256="+hmacHex("my_secret", "my_body"))
+			request.Header.Set("X-GitHub-Delivery", tt.delivery)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestVerifySignature_UnsignedTimestamp(t *testing.T) {
+	var callbackErr error
+	scheme := middleware.HMACSignature("X-Signature", "", "my_secret")
+	scheme.Tolerance = time.Minute
+	handler := middleware.VerifySignature(
+		scheme,
+		middleware.WithSignatureErrorCallback(func(err error, writer http.ResponseWriter, _ *http.Request) {
+			callbackErr = err
+			writer.WriteHeader(http.StatusUnauthorized)
+		}),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {}))
+
+	request := httptest.NewRequest(http.MethodPost, "http://fake-addr/webhook", strings.NewReader("my_body"))
+	request.Header.Set("X-Signature", hmacHex("my_secret", "my_body"))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, middleware.ErrSignatureUnsignedTimestamp, callbackErr)
+}
+
+func TestVerifySignature_MaxBodySize(t *testing.T) {
+	handler := middleware.VerifySignature(
+		middleware.GitHubSignature("my_secret"),
+		middleware.WithMaxBodySize(4),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {}))
+
+	request := httptest.NewRequest(http.MethodPost, "http://fake-addr/webhook", strings.NewReader("my_body"))
+	request.Header.Set("X-Hub-Signature-256", "sha256="+hmacHex("my_secret", "my_body"))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
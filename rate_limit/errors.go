@@ -1,5 +1,46 @@
 package rate_limit
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
 const (
 	RequestLimitReachedErr = "request limit reached"
 )
+
+// ErrLimitReached allows to check a rate limit error with errors.Is(err, rate_limit.ErrLimitReached)
+var ErrLimitReached = errors.New(RequestLimitReachedErr)
+
+// ErrCostExceeded is returned when the cost of a request is above the limit, the request can never be allowed
+// a zero limit rejects every request with a LimitError
+var ErrCostExceeded = errors.New("request cost exceeds the limit")
+
+func costExceeded(cost int, limit int) error {
+	return fmt.Errorf("%w: %d > %d", ErrCostExceeded, cost, limit)
+}
+
+// LimitError is returned by the rate limiters when a limit is reached
+// use errors.As(err, &limitErr) in order to know when the request can be retried
+type LimitError struct {
+	// Key identifies the limit that was reached
+	Key string
+	// Limit is the number of calls allowed per Window
+	Limit int
+	// RetryAfter is the duration until the limit resets
+	RetryAfter time.Duration
+	Window     time.Duration
+}
+
+func (e *LimitError) Error() string {
+	if e.Limit <= 0 || e.Window <= 0 {
+		return RequestLimitReachedErr
+	}
+	return fmt.Sprintf("%s: %d per %s", RequestLimitReachedErr, e.Limit, e.Window)
+}
+
+// Is makes errors.Is(err, ErrLimitReached) works with LimitError
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitReached
+}
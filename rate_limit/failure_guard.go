@@ -0,0 +1,301 @@
+package rate_limit
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+)
+
+const (
+	ClientIPKeyPrefix = "ip:"
+	IdentityKeyPrefix = "identity:"
+)
+
+// LockoutEvent is given to the lockout hook when a client IP or an identity gets locked
+type LockoutEvent struct {
+	// Key is the locked client IP or identity prefixed by ClientIPKeyPrefix or IdentityKeyPrefix
+	Key      string
+	Failures int
+	Until    time.Time
+	Request  *http.Request
+}
+
+type failureCounter struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// FailureGuard counts the failed authentication attempts per client IP and per claimed identity
+// after MaxFailures failures the key is locked for LockoutDuration, doubled on each new failure when Backoff is enabled
+// the failures are forgotten ResetAfter the last one or when the identity authenticates successfully
+type FailureGuard struct {
+	mutex    sync.Mutex
+	counters map[string]*failureCounter
+	config   *FailureGuardConfig
+}
+
+// Allow returns a LimitError when the request client IP or identity is locked
+func (g *FailureGuard) Allow(req *http.Request) error {
+	now := time.Now()
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	for _, key := range g.keys(req) {
+		counter, ok := g.counters[key]
+		if ok && now.Before(counter.lockedUntil) {
+			return &LimitError{
+				Key:        key,
+				Limit:      g.config.MaxFailures,
+				RetryAfter: counter.lockedUntil.Sub(now),
+			}
+		}
+	}
+	return nil
+}
+
+// Failure records a failed attempt of the request client IP and identity
+func (g *FailureGuard) Failure(req *http.Request) {
+	now := time.Now()
+	var events []LockoutEvent
+	g.mutex.Lock()
+	if len(g.counters) >= g.config.MaxEntries {
+		g.cleanup(now)
+	}
+	for _, key := range g.keys(req) {
+		counter, ok := g.counters[key]
+		if !ok && len(g.counters) >= g.config.MaxEntries && !g.evict(key, now) {
+			// every counter is locked, the key is not tracked until there is room
+			continue
+		}
+		if !ok || (now.Sub(counter.lastFailure) > g.config.ResetAfter && !now.Before(counter.lockedUntil)) {
+			counter = &failureCounter{}
+			g.counters[key] = counter
+		}
+		counter.failures++
+		counter.lastFailure = now
+		if counter.failures >= g.config.MaxFailures {
+			counter.lockedUntil = now.Add(g.lockoutDuration(counter.failures))
+			events = append(events, LockoutEvent{Key: key, Failures: counter.failures, Until: counter.lockedUntil, Request: req})
+		}
+	}
+	g.mutex.Unlock()
+
+	if g.config.OnLockout != nil {
+		for _, event := range events {
+			g.config.OnLockout(event)
+		}
+	}
+}
+
+// Success resets the failures of the request identity
+// the client IP failures are kept, an attacker with a valid account must not be able to reset them
+func (g *FailureGuard) Success(req *http.Request) {
+	identity := g.config.Identity(req)
+	if identity == "" {
+		return
+	}
+	g.mutex.Lock()
+	delete(g.counters, IdentityKeyPrefix+identity)
+	g.mutex.Unlock()
+}
+
+// Cleanup removes the expired counters
+func (g *FailureGuard) Cleanup() {
+	g.mutex.Lock()
+	g.cleanup(time.Now())
+	g.mutex.Unlock()
+}
+
+func (g *FailureGuard) cleanup(now time.Time) {
+	for key, counter := range g.counters {
+		if now.Sub(counter.lastFailure) > g.config.ResetAfter && !now.Before(counter.lockedUntil) {
+			delete(g.counters, key)
+		}
+	}
+}
+
+// evict removes the oldest unlocked counter to make room for the key, the client IP counters are evicted first
+// a new identity only evicts a client IP counter, the locked counters are never evicted
+func (g *FailureGuard) evict(key string, now time.Time) bool {
+	if g.evictOldest(ClientIPKeyPrefix, now) {
+		return true
+	}
+	return strings.HasPrefix(key, ClientIPKeyPrefix) && g.evictOldest(IdentityKeyPrefix, now)
+}
+
+// evictOldest removes the unlocked counter with the given key prefix and the oldest failure
+func (g *FailureGuard) evictOldest(prefix string, now time.Time) bool {
+	var oldestKey string
+	var oldest *failureCounter
+	for key, counter := range g.counters {
+		if !strings.HasPrefix(key, prefix) || now.Before(counter.lockedUntil) {
+			continue
+		}
+		if oldest == nil || counter.lastFailure.Before(oldest.lastFailure) {
+			oldestKey, oldest = key, counter
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	delete(g.counters, oldestKey)
+	return true
+}
+
+func (g *FailureGuard) lockoutDuration(failures int) time.Duration {
+	duration := g.config.LockoutDuration
+	if !g.config.Backoff {
+		return duration
+	}
+	for i := g.config.MaxFailures; i < failures && duration < g.config.MaxLockoutDuration; i++ {
+		duration *= 2
+	}
+	if duration > g.config.MaxLockoutDuration {
+		return g.config.MaxLockoutDuration
+	}
+	return duration
+}
+
+func (g *FailureGuard) keys(req *http.Request) []string {
+	keys := make([]string, 0, 2)
+	if ip := g.config.ClientIP(req); ip != "" {
+		keys = append(keys, ClientIPKeyPrefix+ip)
+	}
+	if identity := g.config.Identity(req); identity != "" {
+		keys = append(keys, IdentityKeyPrefix+identity)
+	}
+	return keys
+}
+
+func NewFailureGuard(options ...FailureGuardOption) *FailureGuard {
+	return &FailureGuard{
+		counters: map[string]*failureCounter{},
+		config:   NewFailureGuardConfig(options...),
+	}
+}
+
+// DefaultClientIP returns the host of the request remote address
+func DefaultClientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// DefaultIdentity returns the username of the Basic or Digest Authorization header
+func DefaultIdentity(req *http.Request) string {
+	switch credential := auth.ParseFromHeader(req).(type) {
+	case auth.BasicCredential:
+		return credential.Username
+	case auth.DigestCredential:
+		return credential.Username
+	}
+	return ""
+}
+
+// FailureGuardOption defines a FailureGuard configuration option
+type FailureGuardOption func(*FailureGuardConfig)
+
+type FailureGuardConfig struct {
+	// MaxFailures is the number of failures before the lockout
+	MaxFailures int
+	// LockoutDuration is the duration of the first lockout
+	LockoutDuration time.Duration
+	// Backoff doubles the lockout duration on each failure after the lockout, up to MaxLockoutDuration
+	Backoff            bool
+	MaxLockoutDuration time.Duration
+	// ResetAfter is the duration after which the failures are forgotten
+	ResetAfter time.Duration
+	// MaxEntries is the maximum number of counters, the expired counters are removed when it is reached
+	// then the oldest unlocked client IP counter is evicted for a new key, the locked counters are never evicted
+	MaxEntries int
+	ClientIP   func(*http.Request) string
+	// Identity returns the identity claimed by the request, empty when there is none
+	Identity  func(*http.Request) string
+	OnLockout func(LockoutEvent)
+}
+
+func (c *FailureGuardConfig) apply(options ...FailureGuardOption) *FailureGuardConfig {
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// NewFailureGuardConfig returns a new failure guard configuration with all options applied
+func NewFailureGuardConfig(options ...FailureGuardOption) *FailureGuardConfig {
+	config := &FailureGuardConfig{
+		MaxFailures:        5,
+		LockoutDuration:    time.Minute,
+		MaxLockoutDuration: time.Hour,
+		ResetAfter:         15 * time.Minute,
+		MaxEntries:         10000,
+		ClientIP:           DefaultClientIP,
+		Identity:           DefaultIdentity,
+	}
+	return config.apply(options...)
+}
+
+// WithMaxFailures will configure MaxFailures option
+func WithMaxFailures(maxFailures int) FailureGuardOption {
+	return func(config *FailureGuardConfig) {
+		config.MaxFailures = maxFailures
+	}
+}
+
+// WithLockout will configure LockoutDuration option and disables Backoff
+func WithLockout(duration time.Duration) FailureGuardOption {
+	return func(config *FailureGuardConfig) {
+		config.LockoutDuration = duration
+		config.Backoff = false
+	}
+}
+
+// WithBackoff will configure LockoutDuration, MaxLockoutDuration options and enables Backoff
+func WithBackoff(duration time.Duration, maxDuration time.Duration) FailureGuardOption {
+	return func(config *FailureGuardConfig) {
+		config.LockoutDuration = duration
+		config.MaxLockoutDuration = maxDuration
+		config.Backoff = true
+	}
+}
+
+// WithResetAfter will configure ResetAfter option
+func WithResetAfter(resetAfter time.Duration) FailureGuardOption {
+	return func(config *FailureGuardConfig) {
+		config.ResetAfter = resetAfter
+	}
+}
+
+// WithMaxEntries will configure MaxEntries option
+func WithMaxEntries(maxEntries int) FailureGuardOption {
+	return func(config *FailureGuardConfig) {
+		config.MaxEntries = maxEntries
+	}
+}
+
+// WithClientIP will configure ClientIP option
+func WithClientIP(clientIP func(*http.Request) string) FailureGuardOption {
+	return func(config *FailureGuardConfig) {
+		config.ClientIP = clientIP
+	}
+}
+
+// WithIdentity will configure Identity option
+func WithIdentity(identity func(*http.Request) string) FailureGuardOption {
+	return func(config *FailureGuardConfig) {
+		config.Identity = identity
+	}
+}
+
+// WithLockoutHook will configure OnLockout option
+func WithLockoutHook(hook func(LockoutEvent)) FailureGuardOption {
+	return func(config *FailureGuardConfig) {
+		config.OnLockout = hook
+	}
+}
@@ -0,0 +1,129 @@
+package rate_limit_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/rate_limit"
+	"github.com/stretchr/testify/assert"
+)
+
+func loginRequest(remoteAddr string, username string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "http://fake-addr/login", nil)
+	req.RemoteAddr = remoteAddr
+	req.SetBasicAuth(username, "password")
+	return req
+}
+
+func TestFailureGuard(t *testing.T) {
+	var events []rate_limit.LockoutEvent
+	guard := rate_limit.NewFailureGuard(
+		rate_limit.WithMaxFailures(2),
+		rate_limit.WithLockout(50*time.Millisecond),
+		rate_limit.WithLockoutHook(func(event rate_limit.LockoutEvent) {
+			events = append(events, event)
+		}),
+	)
+
+	assert.NoError(t, guard.Allow(loginRequest("127.0.0.1:1234", "foo")))
+	guard.Failure(loginRequest("127.0.0.1:1234", "foo"))
+	assert.NoError(t, guard.Allow(loginRequest("127.0.0.1:1234", "foo")))
+	guard.Failure(loginRequest("127.0.0.2:1234", "foo"))
+
+	// the identity is locked whatever the client IP
+	err := guard.Allow(loginRequest("127.0.0.3:1234", "foo"))
+	limitErr := &rate_limit.LimitError{}
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, "identity:foo", limitErr.Key)
+	assert.True(t, limitErr.RetryAfter > 0)
+	assert.NoError(t, guard.Allow(loginRequest("127.0.0.1:1234", "bar")))
+	assert.Len(t, events, 1)
+	assert.Equal(t, "identity:foo", events[0].Key)
+	assert.Equal(t, 2, events[0].Failures)
+
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, guard.Allow(loginRequest("127.0.0.3:1234", "foo")))
+}
+
+func TestFailureGuard_ClientIP(t *testing.T) {
+	guard := rate_limit.NewFailureGuard(rate_limit.WithMaxFailures(2))
+
+	guard.Failure(loginRequest("127.0.0.1:1234", "foo"))
+	guard.Failure(loginRequest("127.0.0.1:1234", "bar"))
+	// a success only resets the identity failures
+	guard.Success(loginRequest("127.0.0.1:1234", "foo"))
+
+	err := guard.Allow(loginRequest("127.0.0.1:1234", "baz"))
+	limitErr := &rate_limit.LimitError{}
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, "ip:127.0.0.1", limitErr.Key)
+	assert.NoError(t, guard.Allow(loginRequest("127.0.0.2:1234", "foo")))
+}
+
+func TestFailureGuard_Success(t *testing.T) {
+	guard := rate_limit.NewFailureGuard(rate_limit.WithMaxFailures(2), rate_limit.WithClientIP(func(*http.Request) string {
+		return ""
+	}))
+
+	guard.Failure(loginRequest("127.0.0.1:1234", "foo"))
+	guard.Success(loginRequest("127.0.0.1:1234", "foo"))
+	guard.Failure(loginRequest("127.0.0.1:1234", "foo"))
+	assert.NoError(t, guard.Allow(loginRequest("127.0.0.1:1234", "foo")))
+}
+
+func TestFailureGuard_Backoff(t *testing.T) {
+	var events []rate_limit.LockoutEvent
+	guard := rate_limit.NewFailureGuard(
+		rate_limit.WithMaxFailures(1),
+		rate_limit.WithBackoff(time.Second, 3*time.Second),
+		rate_limit.WithLockoutHook(func(event rate_limit.LockoutEvent) {
+			events = append(events, event)
+		}),
+	)
+
+	for i := 0; i < 4; i++ {
+		guard.Failure(loginRequest("127.0.0.1:1234", ""))
+	}
+	assert.Len(t, events, 4)
+	for i, expected := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		assert.InDelta(t, float64(expected), float64(time.Until(events[i].Until)), float64(100*time.Millisecond))
+	}
+}
+
+func TestFailureGuard_MaxEntries(t *testing.T) {
+	guard := rate_limit.NewFailureGuard(rate_limit.WithMaxFailures(1), rate_limit.WithMaxEntries(2))
+
+	guard.Failure(loginRequest("127.0.0.1:1234", ""))
+	guard.Failure(loginRequest("127.0.0.2:1234", "foo"))
+	// the guard is full of locked counters, the new keys are not tracked
+	assert.NoError(t, guard.Allow(loginRequest("127.0.0.3:1234", "foo")))
+	guard.Failure(loginRequest("127.0.0.3:1234", ""))
+
+	assert.Error(t, guard.Allow(loginRequest("127.0.0.1:1234", "")))
+	assert.Error(t, guard.Allow(loginRequest("127.0.0.2:1234", "")))
+	assert.NoError(t, guard.Allow(loginRequest("127.0.0.3:1234", "")))
+}
+
+func TestFailureGuard_MaxEntries_RotatingIPs(t *testing.T) {
+	guard := rate_limit.NewFailureGuard(rate_limit.WithMaxFailures(2), rate_limit.WithMaxEntries(3))
+
+	guard.Failure(loginRequest("127.0.0.1:1234", ""))
+	guard.Failure(loginRequest("127.0.0.1:1234", ""))
+	// the attacker fills the guard with new client IPs
+	for i := 2; i < 10; i++ {
+		guard.Failure(loginRequest(fmt.Sprintf("127.0.0.%d:1234", i), ""))
+	}
+	for i := 10; i < 20; i++ {
+		guard.Failure(loginRequest(fmt.Sprintf("127.0.0.%d:1234", i), "foo"))
+	}
+
+	// the locked client IP is not evicted
+	assert.Error(t, guard.Allow(loginRequest("127.0.0.1:1234", "")))
+	// the identity is tracked and locked whatever the client IP
+	assert.Error(t, guard.Allow(loginRequest("127.0.0.100:1234", "foo")))
+	assert.NoError(t, guard.Allow(loginRequest("127.0.0.100:1234", "bar")))
+}
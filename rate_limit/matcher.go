@@ -0,0 +1,79 @@
+package rate_limit
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gol4ng/httpware/v4/auth"
+)
+
+// Matcher selects the requests a rate limit policy applies to
+type Matcher func(*http.Request) bool
+
+// MatchAll matches requests that match every given matchers
+func MatchAll(matchers ...Matcher) Matcher {
+	return func(req *http.Request) bool {
+		for _, matcher := range matchers {
+			if !matcher(req) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MatchMethod matches requests with one of the given methods
+func MatchMethod(methods ...string) Matcher {
+	return func(req *http.Request) bool {
+		for _, method := range methods {
+			if strings.EqualFold(req.Method, method) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchPath matches request url path against a shell pattern (see path.Match)
+// ex: "/api/*/export"
+func MatchPath(pattern string) Matcher {
+	return func(req *http.Request) bool {
+		matched, err := path.Match(pattern, req.URL.Path)
+		return err == nil && matched
+	}
+}
+
+// MatchPathPrefix matches requests with an url path starting with prefix
+func MatchPathPrefix(prefix string) Matcher {
+	return func(req *http.Request) bool {
+		return strings.HasPrefix(req.URL.Path, prefix)
+	}
+}
+
+// MatchHeader matches requests with the given header value
+// an empty value matches any request that contains the header
+func MatchHeader(name string, value string) Matcher {
+	return func(req *http.Request) bool {
+		values, ok := req.Header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return false
+		}
+		if value == "" {
+			return true
+		}
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchCredential matches requests with a context credential (see auth.CredentialFromContext) accepted by the given func
+func MatchCredential(match func(auth.Credential) bool) Matcher {
+	return func(req *http.Request) bool {
+		return match(auth.CredentialFromContext(req.Context()))
+	}
+}
@@ -0,0 +1,48 @@
+package rate_limit_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/rate_limit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchers(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "http://fake-addr/api/users/export", nil)
+	request.Header.Set("X-Tenant", "foo")
+	request = request.WithContext(auth.CredentialToContext(request.Context(), "my_credential"))
+
+	tests := []struct {
+		matcher  rate_limit.Matcher
+		expected bool
+	}{
+		{matcher: rate_limit.MatchMethod(http.MethodGet), expected: false},
+		{matcher: rate_limit.MatchMethod(http.MethodGet, "post"), expected: true},
+		{matcher: rate_limit.MatchPath("/api/*/export"), expected: true},
+		{matcher: rate_limit.MatchPath("/api/*"), expected: false},
+		{matcher: rate_limit.MatchPathPrefix("/api/"), expected: true},
+		{matcher: rate_limit.MatchPathPrefix("/admin/"), expected: false},
+		{matcher: rate_limit.MatchHeader("x-tenant", ""), expected: true},
+		{matcher: rate_limit.MatchHeader("X-Tenant", "foo"), expected: true},
+		{matcher: rate_limit.MatchHeader("X-Tenant", "bar"), expected: false},
+		{matcher: rate_limit.MatchHeader("X-Other", ""), expected: false},
+		{matcher: rate_limit.MatchCredential(func(credential auth.Credential) bool {
+			return credential == "my_credential"
+		}), expected: true},
+		{matcher: rate_limit.MatchCredential(func(credential auth.Credential) bool {
+			return credential == nil
+		}), expected: false},
+		{matcher: rate_limit.MatchAll(rate_limit.MatchMethod(http.MethodPost), rate_limit.MatchPathPrefix("/api/")), expected: true},
+		{matcher: rate_limit.MatchAll(rate_limit.MatchMethod(http.MethodPost), rate_limit.MatchPathPrefix("/admin/")), expected: false},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.matcher(request))
+		})
+	}
+}
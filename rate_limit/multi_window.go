@@ -0,0 +1,98 @@
+package rate_limit
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Window represents a fixed window limit, Limit calls allowed per Duration
+// Key is reported in the LimitError when the window is exceeded
+type Window struct {
+	Key      string
+	Duration time.Duration
+	Limit    int
+}
+
+type windowCounter struct {
+	Window
+	start time.Time
+	count int
+}
+
+func (w *windowCounter) refresh(now time.Time) {
+	if now.Sub(w.start) >= w.Duration {
+		w.start = now
+		w.count = 0
+	}
+}
+
+// MultiWindow is a RateLimiter that combines several windows (ex: 10/s AND 500/min AND 10k/day)
+// a request is rejected as soon as one of the windows is exceeded
+// unlike the TokenBucket it doesn't need a goroutine, windows are reset lazily
+type MultiWindow struct {
+	mutex   sync.Mutex
+	windows []*windowCounter
+}
+
+func (m *MultiWindow) Allow(req *http.Request) error {
+	return m.AllowN(req, 1)
+}
+
+// AllowN returns a LimitError when one of the windows cannot accept n calls, or ErrCostExceeded when n is above a non zero window limit
+func (m *MultiWindow) AllowN(_ *http.Request, n int) error {
+	n = clampCost(n)
+	now := time.Now()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, w := range m.windows {
+		if w.Limit > 0 && n > w.Limit {
+			return costExceeded(n, w.Limit)
+		}
+		w.refresh(now)
+		if w.count+n > w.Limit {
+			return &LimitError{
+				Key:        w.Key,
+				Limit:      w.Limit,
+				RetryAfter: w.start.Add(w.Duration).Sub(now),
+				Window:     w.Duration,
+			}
+		}
+	}
+	return nil
+}
+
+func (m *MultiWindow) Inc(req *http.Request) {
+	m.IncN(req, 1)
+}
+
+func (m *MultiWindow) IncN(_ *http.Request, n int) {
+	n = clampCost(n)
+	now := time.Now()
+	m.mutex.Lock()
+	for _, w := range m.windows {
+		w.refresh(now)
+		// the cost is clamped to the limit, a large cost cannot wrap the counter
+		if n > w.Limit {
+			w.count += w.Limit
+			continue
+		}
+		w.count += n
+	}
+	m.mutex.Unlock()
+}
+
+func (m *MultiWindow) Dec(_ *http.Request) {}
+
+func (m *MultiWindow) DecN(_ *http.Request, _ int) {}
+
+func NewMultiWindow(windows ...Window) *MultiWindow {
+	now := time.Now()
+	m := &MultiWindow{
+		windows: make([]*windowCounter, 0, len(windows)),
+	}
+	for _, w := range windows {
+		m.windows = append(m.windows, &windowCounter{Window: w, start: now})
+	}
+	return m
+}
@@ -0,0 +1,86 @@
+package rate_limit_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/rate_limit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiWindow_Allow(t *testing.T) {
+	limiter := rate_limit.NewMultiWindow(
+		rate_limit.Window{Key: "burst", Duration: 5 * time.Millisecond, Limit: 1},
+		rate_limit.Window{Key: "hourly", Duration: time.Hour, Limit: 2},
+	)
+
+	assert.NoError(t, limiter.Allow(nil))
+	limiter.Inc(nil)
+
+	err := limiter.Allow(nil)
+	assert.EqualError(t, err, "request limit reached: 1 per 5ms")
+	assert.True(t, errors.Is(err, rate_limit.ErrLimitReached))
+	limitErr := &rate_limit.LimitError{}
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, "burst", limitErr.Key)
+	assert.Equal(t, 5*time.Millisecond, limitErr.Window)
+	assert.True(t, limitErr.RetryAfter <= 5*time.Millisecond)
+
+	time.Sleep(6 * time.Millisecond)
+	assert.NoError(t, limiter.Allow(nil))
+	limiter.Inc(nil)
+
+	time.Sleep(6 * time.Millisecond)
+	err = limiter.Allow(nil)
+	assert.EqualError(t, err, "request limit reached: 2 per 1h0m0s")
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, "hourly", limitErr.Key)
+	assert.Equal(t, 2, limitErr.Limit)
+	assert.Equal(t, time.Hour, limitErr.Window)
+	assert.True(t, limitErr.RetryAfter > 59*time.Minute)
+}
+
+func TestMultiWindow_AllowN(t *testing.T) {
+	limiter := rate_limit.NewMultiWindow(
+		rate_limit.Window{Key: "minute", Duration: time.Minute, Limit: 10},
+		rate_limit.Window{Key: "hourly", Duration: time.Hour, Limit: 12},
+	)
+
+	limiter.IncN(nil, 8)
+	assert.NoError(t, limiter.AllowN(nil, 2))
+	limitErr := &rate_limit.LimitError{}
+	assert.True(t, errors.As(limiter.AllowN(nil, 3), &limitErr))
+	assert.Equal(t, "minute", limitErr.Key)
+
+	limiter.IncN(nil, 2)
+	assert.True(t, errors.As(limiter.Allow(nil), &limitErr))
+	assert.Equal(t, "minute", limitErr.Key)
+}
+
+func TestMultiWindow_NegativeN(t *testing.T) {
+	limiter := rate_limit.NewMultiWindow(rate_limit.Window{Key: "minute", Duration: time.Minute, Limit: 2})
+
+	// a negative cost costs one token, it does not refund the quota
+	limiter.IncN(nil, 1)
+	limiter.IncN(nil, -5)
+	assert.Error(t, limiter.AllowN(nil, -1))
+	assert.Error(t, limiter.AllowN(nil, 0))
+}
+
+func TestMultiWindow_CostExceeded(t *testing.T) {
+	limiter := rate_limit.NewMultiWindow(
+		rate_limit.Window{Key: "second", Duration: time.Second, Limit: 2},
+		rate_limit.Window{Key: "minute", Duration: time.Minute, Limit: 10},
+	)
+
+	// a cost above the limit can never be allowed
+	err := limiter.AllowN(nil, 3)
+	assert.True(t, errors.Is(err, rate_limit.ErrCostExceeded))
+	assert.False(t, errors.As(err, new(*rate_limit.LimitError)))
+
+	// a huge cost does not wrap the counters
+	limiter.IncN(nil, math.MaxInt)
+	assert.True(t, errors.Is(limiter.AllowN(nil, 1), rate_limit.ErrLimitReached))
+}
@@ -9,3 +9,61 @@ type RateLimiter interface {
 	Inc(req *http.Request)
 	Dec(req *http.Request)
 }
+
+// WeightedRateLimiter is a RateLimiter that allows a request to consume n tokens at once
+type WeightedRateLimiter interface {
+	RateLimiter
+	AllowN(req *http.Request, n int) error
+	IncN(req *http.Request, n int)
+	DecN(req *http.Request, n int)
+}
+
+// CostFunc returns the number of tokens consumed by a request
+type CostFunc func(*http.Request) int
+
+// DefaultCost makes each request consume one token
+func DefaultCost(_ *http.Request) int {
+	return 1
+}
+
+// AllowN checks that the limiter can accept a request that costs n tokens, a cost lower than 1 costs 1 token
+// a RateLimiter that doesn't implement WeightedRateLimiter only checks one token
+func AllowN(limiter RateLimiter, req *http.Request, n int) error {
+	n = clampCost(n)
+	if weighted, ok := limiter.(WeightedRateLimiter); ok {
+		return weighted.AllowN(req, n)
+	}
+	return limiter.Allow(req)
+}
+
+// IncN consumes n tokens, it falls back on n Inc call if the limiter doesn't implement WeightedRateLimiter
+func IncN(limiter RateLimiter, req *http.Request, n int) {
+	n = clampCost(n)
+	if weighted, ok := limiter.(WeightedRateLimiter); ok {
+		weighted.IncN(req, n)
+		return
+	}
+	for i := 0; i < n; i++ {
+		limiter.Inc(req)
+	}
+}
+
+// DecN releases n tokens, it falls back on n Dec call if the limiter doesn't implement WeightedRateLimiter
+func DecN(limiter RateLimiter, req *http.Request, n int) {
+	n = clampCost(n)
+	if weighted, ok := limiter.(WeightedRateLimiter); ok {
+		weighted.DecN(req, n)
+		return
+	}
+	for i := 0; i < n; i++ {
+		limiter.Dec(req)
+	}
+}
+
+// clampCost prevents a negative cost from refunding tokens or wrapping the counters
+func clampCost(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
@@ -0,0 +1,35 @@
+package rate_limit_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/mocks"
+	"github.com/gol4ng/httpware/v4/rate_limit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowN_FallbackOnRateLimiter(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	rateLimiterMock := &mocks.RateLimiter{}
+	rateLimiterMock.On("Allow", req).Return(nil).Once()
+	rateLimiterMock.On("Inc", req).Times(3)
+	rateLimiterMock.On("Dec", req).Times(3)
+
+	assert.NoError(t, rate_limit.AllowN(rateLimiterMock, req, 3))
+	rate_limit.IncN(rateLimiterMock, req, 3)
+	rate_limit.DecN(rateLimiterMock, req, 3)
+
+	rateLimiterMock.AssertExpectations(t)
+}
+
+func TestAllowN_WeightedRateLimiter(t *testing.T) {
+	limiter := rate_limit.NewMultiWindow(rate_limit.Window{Duration: time.Minute, Limit: 4})
+
+	assert.NoError(t, rate_limit.AllowN(limiter, nil, 4))
+	rate_limit.IncN(limiter, nil, 3)
+	assert.Error(t, rate_limit.AllowN(limiter, nil, 2))
+	rate_limit.DecN(limiter, nil, 3)
+	assert.Error(t, rate_limit.AllowN(limiter, nil, 2))
+}
@@ -1,39 +1,69 @@
 package rate_limit
 
 import (
-	"errors"
 	"net/http"
 	"sync"
 	"time"
 )
 
 type TokenBucket struct {
-	mutex     sync.Mutex
-	ticker    *time.Ticker
-	done      chan struct{}
-	callLimit uint32
-	count     uint32
+	mutex      sync.Mutex
+	ticker     *time.Ticker
+	done       chan struct{}
+	timeBucket time.Duration
+	lastReset  time.Time
+	callLimit  uint32
+	count      uint32
 }
 
-func (t *TokenBucket) Allow(_ *http.Request) error {
+func (t *TokenBucket) Allow(req *http.Request) error {
+	return t.AllowN(req, 1)
+}
+
+// AllowN returns a LimitError when the n tokens are not available, or ErrCostExceeded when n is above a non zero limit
+func (t *TokenBucket) AllowN(_ *http.Request, n int) error {
+	n = clampCost(n)
+	if t.callLimit > 0 && uint64(n) > uint64(t.callLimit) {
+		return costExceeded(n, int(t.callLimit))
+	}
 	t.mutex.Lock()
-	res := t.count >= t.callLimit
+	res := uint64(t.count)+uint64(n) > uint64(t.callLimit)
+	lastReset := t.lastReset
 	t.mutex.Unlock()
 	if res {
-		return errors.New(RequestLimitReachedErr)
+		return &LimitError{
+			Limit:      int(t.callLimit),
+			RetryAfter: t.timeBucket - time.Since(lastReset),
+			Window:     t.timeBucket,
+		}
 	}
 
 	return nil
 }
 
-func (t *TokenBucket) Inc(_ *http.Request) {
+func (t *TokenBucket) Inc(req *http.Request) {
+	t.IncN(req, 1)
+}
+
+func (t *TokenBucket) IncN(_ *http.Request, n int) {
 	t.mutex.Lock()
-	t.count++
+	t.count += t.tokens(n)
 	t.mutex.Unlock()
 }
 
+// tokens returns the cost clamped to the limit, a large cost cannot wrap the counter
+func (t *TokenBucket) tokens(n int) uint32 {
+	n = clampCost(n)
+	if uint64(n) > uint64(t.callLimit) {
+		return t.callLimit
+	}
+	return uint32(n)
+}
+
 func (t *TokenBucket) Dec(_ *http.Request) {}
 
+func (t *TokenBucket) DecN(_ *http.Request, _ int) {}
+
 func (t *TokenBucket) Stop() {
 	t.done <- struct{}{}
 	t.ticker.Stop()
@@ -45,9 +75,10 @@ func (t *TokenBucket) start() {
 			select {
 			case <-t.done:
 				return
-			case <-t.ticker.C:
+			case now := <-t.ticker.C:
 				t.mutex.Lock()
 				t.count = 0
+				t.lastReset = now
 				t.mutex.Unlock()
 			}
 		}
@@ -56,9 +87,11 @@ func (t *TokenBucket) start() {
 
 func NewTokenBucket(timeBucket time.Duration, callLimit int) *TokenBucket {
 	t := &TokenBucket{
-		ticker:    time.NewTicker(timeBucket),
-		done:      make(chan struct{}),
-		callLimit: uint32(callLimit),
+		ticker:     time.NewTicker(timeBucket),
+		done:       make(chan struct{}),
+		timeBucket: timeBucket,
+		lastReset:  time.Now(),
+		callLimit:  uint32(callLimit),
 	}
 
 	t.start()
@@ -1,6 +1,8 @@
 package rate_limit_test
 
 import (
+	"errors"
+	"math"
 	"testing"
 	"time"
 
@@ -15,9 +17,56 @@ func TestTokenBucket_Allow(t *testing.T) {
 	assert.NoError(t, limiter.Allow(nil))
 	limiter.Inc(nil)
 
-	assert.EqualError(t, limiter.Allow(nil), "request limit reached")
+	err := limiter.Allow(nil)
+	assert.EqualError(t, err, "request limit reached: 1 per 1ms")
+	assert.True(t, errors.Is(err, rate_limit.ErrLimitReached))
+	limitErr := &rate_limit.LimitError{}
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, 1, limitErr.Limit)
+	assert.Equal(t, 1*time.Millisecond, limitErr.Window)
+	assert.True(t, limitErr.RetryAfter <= 1*time.Millisecond)
 	limiter.Inc(nil)
 
 	time.Sleep(2 * time.Millisecond)
 	assert.NoError(t, limiter.Allow(nil))
 }
+
+func TestTokenBucket_AllowN(t *testing.T) {
+	limiter := rate_limit.NewTokenBucket(1*time.Minute, 5)
+	defer limiter.Stop()
+
+	assert.NoError(t, limiter.AllowN(nil, 5))
+	assert.Error(t, limiter.AllowN(nil, 6))
+	limiter.IncN(nil, 3)
+
+	assert.NoError(t, limiter.AllowN(nil, 2))
+	assert.EqualError(t, limiter.AllowN(nil, 3), "request limit reached: 5 per 1m0s")
+	assert.NoError(t, limiter.Allow(nil))
+}
+
+func TestTokenBucket_NegativeN(t *testing.T) {
+	limiter := rate_limit.NewTokenBucket(1*time.Minute, 2)
+	defer limiter.Stop()
+
+	// a negative cost costs one token, it does not refund the quota
+	limiter.IncN(nil, 2)
+	limiter.IncN(nil, -5)
+	assert.Error(t, limiter.AllowN(nil, -1))
+	assert.Error(t, limiter.AllowN(nil, 0))
+}
+
+func TestTokenBucket_CostExceeded(t *testing.T) {
+	limiter := rate_limit.NewTokenBucket(1*time.Minute, 2)
+	defer limiter.Stop()
+
+	// a cost above the limit can never be allowed
+	err := limiter.AllowN(nil, 3)
+	assert.True(t, errors.Is(err, rate_limit.ErrCostExceeded))
+	assert.False(t, errors.As(err, new(*rate_limit.LimitError)))
+
+	// a huge cost does not wrap the counter
+	err = limiter.AllowN(nil, math.MaxUint32+1)
+	assert.True(t, errors.Is(err, rate_limit.ErrCostExceeded))
+	limiter.IncN(nil, math.MaxUint32+1)
+	assert.True(t, errors.Is(limiter.AllowN(nil, 1), rate_limit.ErrLimitReached))
+}
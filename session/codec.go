@@ -0,0 +1,111 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidCookie = errors.New("invalid session cookie")
+
+// Keys are the cookie signing key and the optional AES encryption key (16, 24 or 32 bytes for AES-128, AES-192 or AES-256)
+type Keys struct {
+	Hash       []byte
+	Encryption []byte
+}
+
+// Codec signs with HMAC-SHA256 and optionally encrypts with AES-GCM the cookie values
+type Codec struct {
+	keys []codecKeys
+}
+
+type codecKeys struct {
+	hash []byte
+	aead cipher.AEAD
+}
+
+// Encode returns base64url(value).base64url(HMAC-SHA256(name|base64url(value))), the value is encrypted first when an encryption key is set
+// the cookie name is part of the signature, a value cannot be moved to another cookie
+func (c *Codec) Encode(name string, value string) (string, error) {
+	keys := c.keys[0]
+	payload := []byte(value)
+	if keys.aead != nil {
+		nonce := make([]byte, keys.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		payload = keys.aead.Seal(nonce, nonce, payload, []byte(name))
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac(keys.hash, name, encoded)), nil
+}
+
+// Decode verifies and decrypts the value with each keys, in order to accept the values encoded before a key rotation
+func (c *Codec) Decode(name string, value string) (string, error) {
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return "", ErrInvalidCookie
+	}
+	rawSignature, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, keys := range c.keys {
+		if !hmac.Equal(rawSignature, mac(keys.hash, name, encoded)) {
+			continue
+		}
+		if keys.aead == nil {
+			return string(payload), nil
+		}
+		nonceSize := keys.aead.NonceSize()
+		if len(payload) < nonceSize {
+			return "", ErrInvalidCookie
+		}
+		plaintext, err := keys.aead.Open(nil, payload[:nonceSize], payload[nonceSize:], []byte(name))
+		if err != nil {
+			return "", ErrInvalidCookie
+		}
+		return string(plaintext), nil
+	}
+	return "", ErrInvalidCookie
+}
+
+func mac(key []byte, name string, value string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name + "|" + value))
+	return h.Sum(nil)
+}
+
+// NewCodec creates a Codec, the first keys encode the values and all the keys decode them (key rotation)
+func NewCodec(keys ...Keys) (*Codec, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("session codec needs at least one key")
+	}
+	codec := &Codec{}
+	for _, k := range keys {
+		if len(k.Hash) == 0 {
+			return nil, errors.New("session codec needs a hash key")
+		}
+		ck := codecKeys{hash: k.Hash}
+		if k.Encryption != nil {
+			block, err := aes.NewCipher(k.Encryption)
+			if err != nil {
+				return nil, err
+			}
+			if ck.aead, err = cipher.NewGCM(block); err != nil {
+				return nil, err
+			}
+		}
+		codec.keys = append(codec.keys, ck)
+	}
+	return codec, nil
+}
@@ -0,0 +1,72 @@
+package session_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodec(t *testing.T) {
+	tests := []session.Keys{
+		{Hash: []byte("my_hash_key")},
+		{Hash: []byte("my_hash_key"), Encryption: []byte("0123456789abcdef0123456789abcdef")},
+	}
+	for i, keys := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			codec, err := session.NewCodec(keys)
+			assert.NoError(t, err)
+
+			encoded, err := codec.Encode("my_cookie", "my_value")
+			assert.NoError(t, err)
+			assert.Equal(t, keys.Encryption == nil, strings.HasPrefix(encoded, "bXlfdmFsdWU."))
+
+			value, err := codec.Decode("my_cookie", encoded)
+			assert.NoError(t, err)
+			assert.Equal(t, "my_value", value)
+
+			// the value cannot be used in another cookie
+			_, err = codec.Decode("other_cookie", encoded)
+			assert.Equal(t, session.ErrInvalidCookie, err)
+
+			_, err = codec.Decode("my_cookie", "bXlfb3RoZXJfdmFsdWU"+encoded[strings.Index(encoded, "."):])
+			assert.Equal(t, session.ErrInvalidCookie, err)
+
+			_, err = codec.Decode("my_cookie", "malformed")
+			assert.Equal(t, session.ErrInvalidCookie, err)
+		})
+	}
+}
+
+func TestCodec_KeyRotation(t *testing.T) {
+	oldKeys := session.Keys{Hash: []byte("my_old_hash_key"), Encryption: []byte("0123456789abcdef")}
+	newKeys := session.Keys{Hash: []byte("my_new_hash_key"), Encryption: []byte("fedcba9876543210")}
+
+	oldCodec, err := session.NewCodec(oldKeys)
+	assert.NoError(t, err)
+	encoded, err := oldCodec.Encode("my_cookie", "my_value")
+	assert.NoError(t, err)
+
+	codec, err := session.NewCodec(newKeys, oldKeys)
+	assert.NoError(t, err)
+	value, err := codec.Decode("my_cookie", encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, "my_value", value)
+
+	// the values are encoded with the new keys
+	encoded, err = codec.Encode("my_cookie", "my_value")
+	assert.NoError(t, err)
+	_, err = oldCodec.Decode("my_cookie", encoded)
+	assert.Equal(t, session.ErrInvalidCookie, err)
+}
+
+func TestNewCodec_Error(t *testing.T) {
+	_, err := session.NewCodec()
+	assert.EqualError(t, err, "session codec needs at least one key")
+	_, err = session.NewCodec(session.Keys{})
+	assert.EqualError(t, err, "session codec needs a hash key")
+	_, err = session.NewCodec(session.Keys{Hash: []byte("my_hash_key"), Encryption: []byte("too short")})
+	assert.EqualError(t, err, "crypto/aes: invalid key size 9")
+}
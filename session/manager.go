@@ -0,0 +1,178 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// maxCookieSize is the cookie size most browsers accept
+const maxCookieSize = 4096
+
+var ErrCookieTooLarge = errors.New("session cookie too large")
+
+// Manager loads and saves the request sessions through the Store, the cookie value is encoded by the Codec
+type Manager struct {
+	store  Store
+	codec  *Codec
+	config *Config
+}
+
+// Load returns the request session, a new one when the cookie is missing, invalid or expired
+// a session that reached the second half of its TTL is renewed (sliding expiration)
+func (m *Manager) Load(req *http.Request) *Session {
+	cookie, err := req.Cookie(m.config.CookieName)
+	if err != nil {
+		return newSession(m.config.TTL)
+	}
+	value, err := m.codec.Decode(m.config.CookieName, cookie.Value)
+	if err != nil {
+		return newSession(m.config.TTL)
+	}
+	session, err := m.store.Load(req.Context(), value)
+	if err != nil || session == nil || !time.Now().Before(session.Expiry) {
+		return newSession(m.config.TTL)
+	}
+	// the store may keep the state flags of the saved session
+	session.isNew, session.modified, session.destroyed, session.previousID = false, false, false, ""
+	if m.config.Sliding && time.Until(session.Expiry) < m.config.TTL/2 {
+		session.Expiry = time.Now().Add(m.config.TTL)
+		session.modified = true
+	}
+	return session
+}
+
+// Save persists the modified session and sets its cookie, a destroyed session gets its cookie removed
+func (m *Manager) Save(writer http.ResponseWriter, req *http.Request, session *Session) error {
+	if session.destroyed {
+		cookie := m.cookie("")
+		cookie.MaxAge = -1
+		http.SetCookie(writer, cookie)
+		if session.isNew {
+			return nil
+		}
+		return m.store.Delete(req.Context(), session)
+	}
+	// an untouched new session is not sent to the client
+	if !session.modified {
+		return nil
+	}
+
+	value, err := m.store.Save(req.Context(), session)
+	if err != nil {
+		return err
+	}
+	encoded, err := m.codec.Encode(m.config.CookieName, value)
+	if err != nil {
+		return err
+	}
+	cookie := m.cookie(encoded)
+	cookie.Expires = session.Expiry
+	if len(cookie.String()) > maxCookieSize {
+		return ErrCookieTooLarge
+	}
+	http.SetCookie(writer, cookie)
+	session.modified = false
+	return nil
+}
+
+func (m *Manager) cookie(value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.config.CookieName,
+		Value:    value,
+		Path:     m.config.Path,
+		Domain:   m.config.Domain,
+		Secure:   m.config.Secure,
+		HttpOnly: m.config.HTTPOnly,
+		SameSite: m.config.SameSite,
+	}
+}
+
+// NewManager creates a session Manager
+func NewManager(store Store, codec *Codec, options ...Option) *Manager {
+	return &Manager{
+		store:  store,
+		codec:  codec,
+		config: NewConfig(options...),
+	}
+}
+
+type Config struct {
+	CookieName string
+	Path       string
+	Domain     string
+	Secure     bool
+	HTTPOnly   bool
+	SameSite   http.SameSite
+	// TTL is the session lifetime
+	TTL time.Duration
+	// Sliding renews the session expiration while it is used
+	Sliding bool
+}
+
+func (c *Config) apply(options ...Option) *Config {
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// NewConfig returns a new session configuration with all options applied
+func NewConfig(options ...Option) *Config {
+	config := &Config{
+		CookieName: "session",
+		Path:       "/",
+		Secure:     true,
+		HTTPOnly:   true,
+		SameSite:   http.SameSiteLaxMode,
+		TTL:        24 * time.Hour,
+		Sliding:    true,
+	}
+	return config.apply(options...)
+}
+
+// Option defines a session Manager configuration option
+type Option func(*Config)
+
+// WithCookieName will configure CookieName option
+func WithCookieName(cookieName string) Option {
+	return func(config *Config) {
+		config.CookieName = cookieName
+	}
+}
+
+// WithCookiePath will configure Path option
+func WithCookiePath(path string) Option {
+	return func(config *Config) {
+		config.Path = path
+	}
+}
+
+// WithCookieDomain will configure Domain option
+func WithCookieDomain(domain string) Option {
+	return func(config *Config) {
+		config.Domain = domain
+	}
+}
+
+// WithSecure will configure Secure option
+func WithSecure(secure bool) Option {
+	return func(config *Config) {
+		config.Secure = secure
+	}
+}
+
+// WithSameSite will configure SameSite option
+func WithSameSite(sameSite http.SameSite) Option {
+	return func(config *Config) {
+		config.SameSite = sameSite
+	}
+}
+
+// WithTTL will configure TTL and Sliding options
+func WithTTL(ttl time.Duration, sliding bool) Option {
+	return func(config *Config) {
+		config.TTL = ttl
+		config.Sliding = sliding
+	}
+}
@@ -0,0 +1,146 @@
+package session_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func newManager(t *testing.T, store session.Store, options ...session.Option) *session.Manager {
+	codec, err := session.NewCodec(session.Keys{Hash: []byte("my_hash_key"), Encryption: []byte("0123456789abcdef")})
+	assert.NoError(t, err)
+	return session.NewManager(store, codec, options...)
+}
+
+// roundTrip loads the request session, applies the callback and returns the response cookie
+func roundTrip(t *testing.T, manager *session.Manager, cookie *http.Cookie, callback func(*session.Session)) (*session.Session, *http.Cookie) {
+	request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	if cookie != nil {
+		request.AddCookie(cookie)
+	}
+	s := manager.Load(request)
+	callback(s)
+	recorder := httptest.NewRecorder()
+	assert.NoError(t, manager.Save(recorder, request, s))
+	cookies := recorder.Result().Cookies()
+	if len(cookies) == 0 {
+		return s, nil
+	}
+	return s, cookies[0]
+}
+
+func TestManager(t *testing.T) {
+	stores := []session.Store{session.CookieStore{}, session.NewMemoryStore()}
+	for i, store := range stores {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			manager := newManager(t, store)
+
+			// an untouched new session sets no cookie
+			s, cookie := roundTrip(t, manager, nil, func(s *session.Session) {})
+			assert.True(t, s.IsNew())
+			assert.Nil(t, cookie)
+
+			s, cookie = roundTrip(t, manager, nil, func(s *session.Session) {
+				s.Set("my_key", "my_value")
+			})
+			assert.NotNil(t, cookie)
+			assert.Equal(t, "session", cookie.Name)
+			assert.True(t, cookie.HttpOnly)
+			assert.True(t, cookie.Secure)
+			assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+			id := s.ID
+
+			s, unchanged := roundTrip(t, manager, cookie, func(s *session.Session) {
+				assert.False(t, s.IsNew())
+				assert.Equal(t, id, s.ID)
+				assert.Equal(t, "my_value", s.Get("my_key"))
+			})
+			assert.Nil(t, unchanged)
+
+			s, cookie = roundTrip(t, manager, cookie, func(s *session.Session) {
+				s.Login("foo")
+			})
+			assert.NotEqual(t, id, s.ID)
+			assert.Equal(t, "foo", s.UserID)
+
+			s, cookie = roundTrip(t, manager, cookie, func(s *session.Session) {
+				assert.Equal(t, "foo", s.UserID)
+				assert.Equal(t, "my_value", s.Get("my_key"))
+				s.Logout()
+			})
+			assert.Equal(t, -1, cookie.MaxAge)
+
+			s, _ = roundTrip(t, manager, cookie, func(s *session.Session) {})
+			assert.True(t, s.IsNew())
+		})
+	}
+}
+
+func TestManager_InvalidCookie(t *testing.T) {
+	manager := newManager(t, session.CookieStore{})
+	s, _ := roundTrip(t, manager, &http.Cookie{Name: "session", Value: "forged"}, func(s *session.Session) {})
+	assert.True(t, s.IsNew())
+}
+
+func TestManager_Expiry(t *testing.T) {
+	manager := newManager(t, session.NewMemoryStore(), session.WithTTL(40*time.Millisecond, true))
+
+	_, cookie := roundTrip(t, manager, nil, func(s *session.Session) {
+		s.Set("my_key", "my_value")
+	})
+
+	// the session is renewed in the second half of its ttl
+	time.Sleep(25 * time.Millisecond)
+	s, renewed := roundTrip(t, manager, cookie, func(s *session.Session) {})
+	assert.False(t, s.IsNew())
+	assert.NotNil(t, renewed)
+
+	time.Sleep(25 * time.Millisecond)
+	s, _ = roundTrip(t, manager, renewed, func(s *session.Session) {})
+	assert.False(t, s.IsNew())
+
+	time.Sleep(50 * time.Millisecond)
+	s, _ = roundTrip(t, manager, renewed, func(s *session.Session) {})
+	assert.True(t, s.IsNew())
+}
+
+func TestManager_CookieTooLarge(t *testing.T) {
+	manager := newManager(t, session.CookieStore{})
+	request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	s := manager.Load(request)
+	s.Set("my_key", strings.Repeat("a", 4096))
+	assert.Equal(t, session.ErrCookieTooLarge, manager.Save(httptest.NewRecorder(), request, s))
+}
+
+func TestMemoryStore_Cleanup(t *testing.T) {
+	store := session.NewMemoryStore()
+	manager := newManager(t, store, session.WithTTL(time.Millisecond, false))
+	_, cookie := roundTrip(t, manager, nil, func(s *session.Session) {
+		s.Set("my_key", "my_value")
+	})
+	time.Sleep(2 * time.Millisecond)
+	store.Cleanup()
+
+	s, _ := roundTrip(t, newManager(t, store), cookie, func(s *session.Session) {})
+	assert.True(t, s.IsNew())
+}
+
+func TestSession_Principal(t *testing.T) {
+	s := &session.Session{UserID: "foo", Values: map[string]string{"csrf_token": "my_token"}, Expiry: time.Unix(10, 0)}
+	s.SetClaim("my_key", "my_value")
+	var principal auth.Principal = s
+	assert.Equal(t, "foo", principal.Subject())
+	// the values are private, only the claims are exposed
+	assert.Equal(t, map[string]interface{}{"my_key": "my_value"}, principal.Claims())
+	principal.Claims()["my_key"] = "modified"
+	assert.Equal(t, "my_value", s.ClaimValues["my_key"])
+	assert.Equal(t, time.Unix(10, 0), principal.ExpiresAt())
+	assert.Equal(t, auth.AuthMethodSession, principal.AuthMethod())
+}
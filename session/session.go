@@ -0,0 +1,148 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+)
+
+// Session holds the data of a client across requests
+type Session struct {
+	ID string `json:"id"`
+	// UserID is the authenticated principal, empty for anonymous sessions
+	UserID string `json:"user_id,omitempty"`
+	// Values are private to the application and the middlewares (ie: the CSRF token), they are not exposed as claims
+	Values map[string]string `json:"values,omitempty"`
+	// ClaimValues are the principal claims of the session (see SetClaim)
+	ClaimValues map[string]string `json:"claims,omitempty"`
+	Expiry      time.Time         `json:"expiry"`
+
+	isNew      bool
+	modified   bool
+	destroyed  bool
+	previousID string
+}
+
+func (s *Session) Get(key string) string {
+	return s.Values[key]
+}
+
+func (s *Session) Set(key string, value string) {
+	if s.Values == nil {
+		s.Values = map[string]string{}
+	}
+	s.Values[key] = value
+	s.modified = true
+}
+
+func (s *Session) Delete(key string) {
+	delete(s.Values, key)
+	s.modified = true
+}
+
+// SetClaim sets a value exposed in the principal claims (see Claims)
+func (s *Session) SetClaim(key string, value string) {
+	if s.ClaimValues == nil {
+		s.ClaimValues = map[string]string{}
+	}
+	s.ClaimValues[key] = value
+	s.modified = true
+}
+
+// Login sets the authenticated user, the session ID is regenerated to prevent session fixation
+func (s *Session) Login(userID string) {
+	s.UserID = userID
+	s.Regenerate()
+}
+
+// Logout destroys the session, its cookie is removed
+func (s *Session) Logout() {
+	s.UserID = ""
+	s.Values = nil
+	s.ClaimValues = nil
+	s.destroyed = true
+	s.modified = true
+}
+
+// Regenerate gives a new ID to the session, the previous one is deleted from the store
+func (s *Session) Regenerate() {
+	if s.previousID == "" && !s.isNew {
+		s.previousID = s.ID
+	}
+	s.ID = newID()
+	s.modified = true
+}
+
+// Subject returns the session user ID
+func (s *Session) Subject() string {
+	return s.UserID
+}
+
+func (s *Session) Issuer() string {
+	return ""
+}
+
+func (s *Session) Scopes() []string {
+	return nil
+}
+
+// Claims returns a copy of the session claims, the session Values are not included
+func (s *Session) Claims() map[string]interface{} {
+	claims := make(map[string]interface{}, len(s.ClaimValues))
+	for key, value := range s.ClaimValues {
+		claims[key] = value
+	}
+	return claims
+}
+
+func (s *Session) ExpiresAt() time.Time {
+	return s.Expiry
+}
+
+func (s *Session) AuthMethod() string {
+	return auth.AuthMethodSession
+}
+
+// IsNew reports whether the session was created by the current request
+func (s *Session) IsNew() bool {
+	return s.isNew
+}
+
+// PreviousID returns the ID replaced by Regenerate, the stores use it to delete the previous session
+func (s *Session) PreviousID() string {
+	return s.previousID
+}
+
+func newSession(ttl time.Duration) *Session {
+	return &Session{
+		ID:     newID(),
+		Expiry: time.Now().Add(ttl),
+		isNew:  true,
+	}
+}
+
+func newID() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+type contextKey struct{}
+
+var sessionContextKey = contextKey{}
+
+func ToContext(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey, session)
+}
+
+// FromContext returns the request session, nil when the Session middleware is not used
+func FromContext(ctx context.Context) *Session {
+	if ctx == nil {
+		return nil
+	}
+	session, _ := ctx.Value(sessionContextKey).(*Session)
+	return session
+}
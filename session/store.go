@@ -0,0 +1,112 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Store persists the sessions, the cookie holds the value returned by Save
+type Store interface {
+	// Load returns the session of the cookie value, nil when it does not exist anymore
+	Load(ctx context.Context, value string) (*Session, error)
+	// Save persists the session and returns the cookie value
+	Save(ctx context.Context, session *Session) (string, error)
+	// Delete removes the session
+	Delete(ctx context.Context, session *Session) error
+}
+
+// CookieStore keeps the whole session in the cookie, it must stay under the 4KB cookie size
+type CookieStore struct{}
+
+func (CookieStore) Load(_ context.Context, value string) (*Session, error) {
+	session := &Session{}
+	if err := json.Unmarshal([]byte(value), session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (CookieStore) Save(_ context.Context, session *Session) (string, error) {
+	value, err := json.Marshal(session)
+	return string(value), err
+}
+
+func (CookieStore) Delete(_ context.Context, _ *Session) error {
+	return nil
+}
+
+// MemoryStore keeps the sessions in memory, the cookie only holds the session ID
+type MemoryStore struct {
+	mutex    sync.RWMutex
+	sessions map[string]Session
+}
+
+func (m *MemoryStore) Load(_ context.Context, id string) (*Session, error) {
+	m.mutex.RLock()
+	session, ok := m.sessions[id]
+	m.mutex.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	if !time.Now().Before(session.Expiry) {
+		m.mutex.Lock()
+		delete(m.sessions, id)
+		m.mutex.Unlock()
+		return nil, nil
+	}
+	session.Values = copyValues(session.Values)
+	session.ClaimValues = copyValues(session.ClaimValues)
+	return &session, nil
+}
+
+func (m *MemoryStore) Save(_ context.Context, session *Session) (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if session.PreviousID() != "" {
+		delete(m.sessions, session.PreviousID())
+	}
+	stored := *session
+	stored.Values = copyValues(session.Values)
+	stored.ClaimValues = copyValues(session.ClaimValues)
+	m.sessions[session.ID] = stored
+	return session.ID, nil
+}
+
+func (m *MemoryStore) Delete(_ context.Context, session *Session) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.sessions, session.ID)
+	delete(m.sessions, session.PreviousID())
+	return nil
+}
+
+// Cleanup removes the expired sessions
+func (m *MemoryStore) Cleanup() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := time.Now()
+	for id, session := range m.sessions {
+		if !now.Before(session.Expiry) {
+			delete(m.sessions, id)
+		}
+	}
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: map[string]Session{},
+	}
+}
+
+func copyValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	c := make(map[string]string, len(values))
+	for key, value := range values {
+		c[key] = value
+	}
+	return c
+}
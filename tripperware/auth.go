@@ -1,22 +1,63 @@
 package tripperware
 
 import (
+	"context"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gol4ng/httpware/v4"
 	"github.com/gol4ng/httpware/v4/auth"
+	"golang.org/x/sync/singleflight"
 )
 
+// AuthenticationForwarder tripperware forwards the credential of the request context
+// with a CredentialRefresher, an expired credential is refreshed before being forwarded and when the server answers 401
+// the credential is refreshed once and the request is sent again with its body replayed
 func AuthenticationForwarder(options ...AuthOption) httpware.Tripperware {
 	config := NewAuthConfig(options...)
 	return func(next http.RoundTripper) http.RoundTripper {
+		if config.credentialRefresher == nil {
+			return httpware.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+				config.credentialForwarder(req)
+				return next.RoundTrip(req)
+			})
+		}
+
+		refresher := &credentialRefresh{
+			refresher:  config.credentialRefresher,
+			maxEntries: config.refreshCacheSize,
+			refreshed:  map[string]*refreshedCredential{},
+		}
 		return httpware.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
-			config.credentialForwarder(req)
-			return next.RoundTrip(req)
+			original := auth.CredentialFromContext(req.Context())
+			credential, err := refresher.current(req.Context(), original)
+			if err != nil {
+				return nil, err
+			}
+			req = replayable(req)
+
+			resp, err := next.RoundTrip(config.forward(req, credential))
+			if err != nil || resp.StatusCode != http.StatusUnauthorized || original == nil {
+				return resp, err
+			}
+
+			fresh, err := refresher.refresh(req.Context(), original, credential)
+			if err != nil {
+				return resp, nil
+			}
+			retry, ok := retryRequest(req, resp)
+			if !ok {
+				return resp, nil
+			}
+			return next.RoundTrip(config.forward(retry, fresh))
 		})
 	}
 }
 
+// CredentialRefresher returns a new credential replacing the expired or rejected one
+type CredentialRefresher func(ctx context.Context, credential auth.Credential) (auth.Credential, error)
+
 type credentialForwarder func(req *http.Request)
 
 // AuthOption defines a interceptor tripperware configuration option
@@ -24,6 +65,8 @@ type AuthOption func(*AuthConfig)
 
 type AuthConfig struct {
 	credentialForwarder credentialForwarder
+	credentialRefresher CredentialRefresher
+	refreshCacheSize    int
 }
 
 func (o *AuthConfig) apply(options ...AuthOption) {
@@ -32,9 +75,17 @@ func (o *AuthConfig) apply(options ...AuthOption) {
 	}
 }
 
+// forward returns a copy of the request with the credential in its context and forwarded by the credentialForwarder
+func (o *AuthConfig) forward(req *http.Request, credential auth.Credential) *http.Request {
+	r := req.Clone(auth.CredentialToContext(req.Context(), credential))
+	o.credentialForwarder(r)
+	return r
+}
+
 func NewAuthConfig(options ...AuthOption) *AuthConfig {
 	opts := &AuthConfig{
 		credentialForwarder: DefaultCredentialForwarder,
+		refreshCacheSize:    100,
 	}
 	opts.apply(options...)
 	return opts
@@ -50,3 +101,110 @@ func WithCredentialForwarder(authFunc credentialForwarder) AuthOption {
 		config.credentialForwarder = authFunc
 	}
 }
+
+// WithCredentialRefresher will configure credentialRefresher option
+// cacheSize is the number of refreshed credentials kept to replace the context credentials of the next requests
+func WithCredentialRefresher(refresher CredentialRefresher, cacheSize int) AuthOption {
+	return func(config *AuthConfig) {
+		config.credentialRefresher = refresher
+		config.refreshCacheSize = cacheSize
+	}
+}
+
+// credentialRefresh keeps the refreshed credentials by original credential key
+// and coalesces the concurrent refreshes of the same credential
+type credentialRefresh struct {
+	refresher  CredentialRefresher
+	maxEntries int
+
+	group     singleflight.Group
+	mutex     sync.RWMutex
+	refreshed map[string]*refreshedCredential
+}
+
+type refreshedCredential struct {
+	credential  auth.Credential
+	refreshedAt time.Time
+}
+
+// current returns the latest credential replacing the original one, it is refreshed when it is expired
+func (c *credentialRefresh) current(ctx context.Context, original auth.Credential) (auth.Credential, error) {
+	if original == nil {
+		return nil, nil
+	}
+	credential := original
+	if key, ok := auth.CredentialKey(original); ok {
+		c.mutex.RLock()
+		if refreshed, ok := c.refreshed[key]; ok {
+			credential = refreshed.credential
+		}
+		c.mutex.RUnlock()
+	}
+	if expirable, ok := credential.(auth.Expirable); ok {
+		if exp := expirable.ExpiresAt(); !exp.IsZero() && !time.Now().Before(exp) {
+			return c.refresh(ctx, original, credential)
+		}
+	}
+	return credential, nil
+}
+
+// refresh replaces the stale credential, unless a concurrent request already did it
+func (c *credentialRefresh) refresh(ctx context.Context, original auth.Credential, stale auth.Credential) (auth.Credential, error) {
+	key, ok := auth.CredentialKey(original)
+	if !ok {
+		return c.refresher(ctx, stale)
+	}
+	result, err, _ := c.group.Do(key, func() (interface{}, error) {
+		c.mutex.RLock()
+		refreshed, ok := c.refreshed[key]
+		c.mutex.RUnlock()
+		if ok && !sameCredential(refreshed.credential, stale) {
+			return refreshed.credential, nil
+		}
+
+		fresh, err := c.refresher(ctx, stale)
+		if err != nil {
+			return nil, err
+		}
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		if _, ok := c.refreshed[key]; !ok && len(c.refreshed) >= c.maxEntries {
+			c.evict()
+		}
+		c.refreshed[key] = &refreshedCredential{credential: fresh, refreshedAt: time.Now()}
+		return fresh, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(auth.Credential), nil
+}
+
+// evict removes the expired credentials, or the oldest one when none is expired
+func (c *credentialRefresh) evict() {
+	now := time.Now()
+	var oldestKey string
+	var oldest *refreshedCredential
+	for key, refreshed := range c.refreshed {
+		if expirable, ok := refreshed.credential.(auth.Expirable); ok {
+			if exp := expirable.ExpiresAt(); !exp.IsZero() && !now.Before(exp) {
+				delete(c.refreshed, key)
+				continue
+			}
+		}
+		if oldest == nil || refreshed.refreshedAt.Before(oldest.refreshedAt) {
+			oldestKey, oldest = key, refreshed
+		}
+	}
+	if len(c.refreshed) >= c.maxEntries && oldest != nil {
+		delete(c.refreshed, oldestKey)
+	}
+}
+
+// sameCredential compares the credentials by key, the credentials without key are considered the same
+// so the rejected credential is always refreshed
+func sameCredential(a auth.Credential, b auth.Credential) bool {
+	keyA, okA := auth.CredentialKey(a)
+	keyB, okB := auth.CredentialKey(b)
+	return !okA || !okB || keyA == keyB
+}
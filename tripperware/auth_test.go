@@ -2,10 +2,16 @@ package tripperware_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gol4ng/httpware/v4/auth"
 	"github.com/gol4ng/httpware/v4/mocks"
@@ -60,3 +66,133 @@ func TestAuthenticationForwarder_CustomCredentialForwarder(t *testing.T) {
 	}))(roundTripperMock).RoundTrip(request)
 	roundTripperMock.AssertExpectations(t)
 }
+
+type expiringToken struct {
+	token  string
+	expiry time.Time
+}
+
+func (t *expiringToken) Scheme() string {
+	return auth.BearerScheme
+}
+
+func (t *expiringToken) Parameters() string {
+	return t.token
+}
+
+func (t *expiringToken) ExpiresAt() time.Time {
+	return t.expiry
+}
+
+func TestAuthenticationForwarder_CredentialRefresher(t *testing.T) {
+	var refreshes int32
+	refresher := func(_ context.Context, credential auth.Credential) (auth.Credential, error) {
+		n := atomic.AddInt32(&refreshes, 1)
+		time.Sleep(10 * time.Millisecond)
+		return &expiringToken{token: fmt.Sprintf("refreshed_%d", n), expiry: time.Now().Add(time.Hour)}, nil
+	}
+
+	var mutex sync.Mutex
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		assert.Equal(t, "my_body", string(body))
+		authorization := request.Header.Get(auth.AuthorizationHeader)
+		mutex.Lock()
+		authorizations = append(authorizations, authorization)
+		mutex.Unlock()
+		if authorization == "Bearer revoked_token" {
+			writer.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: tripperware.AuthenticationForwarder(tripperware.WithCredentialRefresher(refresher, 10))(http.DefaultTransport),
+	}
+	post := func(credential auth.Credential) int {
+		request, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("my_body"))
+		resp, err := client.Do(request.WithContext(auth.CredentialToContext(context.Background(), credential)))
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	// the concurrent refreshes of the same credential are coalesced
+	revoked := &expiringToken{token: "revoked_token", expiry: time.Now().Add(time.Hour)}
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, http.StatusOK, post(revoked))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), refreshes)
+
+	// the refreshed credential replaces the context credential of the next requests
+	mutex.Lock()
+	authorizations = nil
+	mutex.Unlock()
+	assert.Equal(t, http.StatusOK, post(revoked))
+	assert.Equal(t, []string{"Bearer refreshed_1"}, authorizations)
+
+	// an expired credential is refreshed before the request
+	authorizations = nil
+	assert.Equal(t, http.StatusOK, post(&expiringToken{token: "expired_token", expiry: time.Now().Add(-time.Second)}))
+	assert.Equal(t, []string{"Bearer refreshed_2"}, authorizations)
+	assert.Equal(t, int32(2), refreshes)
+}
+
+func TestAuthenticationForwarder_CredentialRefresherEviction(t *testing.T) {
+	var refreshes int32
+	refresher := func(_ context.Context, credential auth.Credential) (auth.Credential, error) {
+		n := atomic.AddInt32(&refreshes, 1)
+		time.Sleep(5 * time.Millisecond)
+		return &expiringToken{token: fmt.Sprintf("refreshed_%d", n)}, nil
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if strings.HasPrefix(request.Header.Get(auth.AuthorizationHeader), "Bearer revoked") {
+			writer.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: tripperware.AuthenticationForwarder(tripperware.WithCredentialRefresher(refresher, 2))(http.DefaultTransport),
+	}
+	get := func(token string) {
+		request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := client.Do(request.WithContext(auth.CredentialToContext(context.Background(), auth.BearerToken(token))))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	get("revoked_a")
+	get("revoked_b")
+	// the oldest refreshed credential is evicted
+	get("revoked_c")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&refreshes))
+	get("revoked_b")
+	get("revoked_c")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&refreshes))
+	get("revoked_a")
+	assert.Equal(t, int32(4), atomic.LoadInt32(&refreshes))
+}
+
+func TestAuthenticationForwarder_CredentialRefresherError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: tripperware.AuthenticationForwarder(tripperware.WithCredentialRefresher(func(_ context.Context, _ auth.Credential) (auth.Credential, error) {
+			return nil, errors.New("my_refresh_error")
+		}, 10))(http.DefaultTransport),
+	}
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(request.WithContext(auth.CredentialToContext(context.Background(), "Bearer my_token")))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
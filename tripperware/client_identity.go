@@ -0,0 +1,36 @@
+package tripperware
+
+import (
+	"net/http"
+
+	"github.com/gol4ng/httpware/v4"
+	"github.com/gol4ng/httpware/v4/auth"
+)
+
+// ClientIdentityForwarder tripperware signs the client identity authenticated by the
+// auth.ClientCertificateAuthenticator and sends it in the auth.ClientIdentityHeader to the internal services
+func ClientIdentityForwarder(key []byte) httpware.Tripperware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return httpware.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			var identity *auth.ClientIdentity
+			switch credential := auth.CredentialFromContext(req.Context()).(type) {
+			case *auth.ClientCertificate:
+				if credential != nil {
+					identity = &credential.ClientIdentity
+				}
+			case *auth.ClientIdentity:
+				identity = credential
+			}
+			if identity == nil {
+				return next.RoundTrip(req)
+			}
+			signed, err := auth.SignClientIdentity(key, *identity)
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Header.Set(auth.ClientIdentityHeader, string(signed))
+			return next.RoundTrip(req)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package tripperware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/mocks"
+	"github.com/gol4ng/httpware/v4/tripperware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestClientIdentityForwarder(t *testing.T) {
+	var signed string
+	roundTripperMock := &mocks.RoundTripper{}
+	roundTripperMock.On("RoundTrip", mock.AnythingOfType("*http.Request")).Return(nil, nil).Run(func(args mock.Arguments) {
+		signed = args.Get(0).(*http.Request).Header.Get(auth.ClientIdentityHeader)
+	})
+	forwarder := tripperware.ClientIdentityForwarder([]byte("my_key"))(roundTripperMock)
+
+	_, _ = forwarder.RoundTrip(httptest.NewRequest(http.MethodGet, "http://fake-addr", nil))
+	assert.Empty(t, signed)
+
+	request := httptest.NewRequest(http.MethodGet, "http://fake-addr", nil)
+	request = request.WithContext(auth.CredentialToContext(context.Background(), &auth.ClientCertificate{
+		ClientIdentity: auth.ClientIdentity{CommonName: "my_client"},
+	}))
+	_, _ = forwarder.RoundTrip(request)
+	identity, err := auth.VerifyClientIdentity([]byte("my_key"), auth.SignedClientIdentity(signed), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, &auth.ClientIdentity{CommonName: "my_client"}, identity)
+	assert.Empty(t, request.Header.Get(auth.ClientIdentityHeader))
+}
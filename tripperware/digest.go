@@ -0,0 +1,122 @@
+package tripperware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gol4ng/httpware/v4"
+	"github.com/gol4ng/httpware/v4/auth"
+)
+
+// DigestAuthentication tripperware answers the Digest challenges (RFC 7616) with the given username and password
+// the request is sent again with the computed credential when the server answers 401, the body is replayed
+// the last challenge of each host is reused with an incremented nonce count for the next requests to this host
+func DigestAuthentication(username string, password string) httpware.Tripperware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		sessions := &digestSessions{sessions: map[string]*digestSession{}}
+		return httpware.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			req = replayable(req)
+
+			session := sessions.get(req.URL.Host)
+			r := req
+			if challenge, nc := session.next(); challenge != nil {
+				var err error
+				if r, err = withDigestCredential(req, challenge, nc, username, password); err != nil {
+					return nil, err
+				}
+			}
+			resp, err := next.RoundTrip(r)
+			if err != nil || resp.StatusCode != http.StatusUnauthorized {
+				return resp, err
+			}
+
+			challenge := strongestDigestChallenge(resp.Header.Values("WWW-Authenticate"))
+			if challenge == nil {
+				return resp, nil
+			}
+			session.reset(challenge)
+			_, nc := session.next()
+			retry, ok := retryRequest(req, resp)
+			if !ok {
+				return resp, nil
+			}
+			if retry, err = withDigestCredential(retry, challenge, nc, username, password); err != nil {
+				return nil, err
+			}
+			return next.RoundTrip(retry)
+		})
+	}
+}
+
+// digestSessions holds a session per host, the challenge of a host must not be sent to another one
+type digestSessions struct {
+	mutex    sync.Mutex
+	sessions map[string]*digestSession
+}
+
+func (s *digestSessions) get(host string) *digestSession {
+	host = strings.ToLower(host)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	session, ok := s.sessions[host]
+	if !ok {
+		session = &digestSession{}
+		s.sessions[host] = session
+	}
+	return session
+}
+
+type digestSession struct {
+	mutex     sync.Mutex
+	challenge *auth.DigestChallenge
+	nc        uint32
+}
+
+func (s *digestSession) next() (*auth.DigestChallenge, uint32) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.challenge == nil {
+		return nil, 0
+	}
+	s.nc++
+	return s.challenge, s.nc
+}
+
+func (s *digestSession) reset(challenge *auth.DigestChallenge) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.challenge = challenge
+	s.nc = 0
+}
+
+// strongestDigestChallenge returns the supported challenge with the strongest algorithm
+func strongestDigestChallenge(headers []string) *auth.DigestChallenge {
+	var strongest *auth.DigestChallenge
+	for _, header := range headers {
+		challenge, err := auth.ParseDigestChallenge(header)
+		if err != nil || !challenge.Supported() {
+			continue
+		}
+		if strongest == nil || (strings.HasPrefix(challenge.Algorithm, auth.DigestSHA256) && !strings.HasPrefix(strongest.Algorithm, auth.DigestSHA256)) {
+			strongest = challenge
+		}
+	}
+	return strongest
+}
+
+func withDigestCredential(req *http.Request, challenge *auth.DigestChallenge, nc uint32, username string, password string) (*http.Request, error) {
+	cnonce := make([]byte, 16)
+	if _, err := rand.Read(cnonce); err != nil {
+		return nil, err
+	}
+	credential, err := challenge.Authorize(req.Method, req.URL.RequestURI(), username, password, hex.EncodeToString(cnonce), nc)
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	r.Header.Set(auth.AuthorizationHeader, auth.FormatCredential(credential))
+	return r, nil
+}
@@ -0,0 +1,147 @@
+package tripperware_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4"
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/middleware"
+	"github.com/gol4ng/httpware/v4/tripperware"
+	"github.com/stretchr/testify/assert"
+)
+
+func digestHandler(next http.Handler) http.Handler {
+	return middleware.Authentication(middleware.NewAuthenticateFunc(
+		auth.NewDigestAuthenticator("my_realm", auth.DigestPasswords{"foo": "my_password"}),
+		middleware.WithCredentialFinder(middleware.DigestCredentialFinder),
+	))(next)
+}
+
+func TestDigestAuthentication(t *testing.T) {
+	var authorizations []string
+	handler := digestHandler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		_, _ = writer.Write([]byte(auth.PrincipalFromContext(request.Context()).Subject() + " " + string(body)))
+	}))
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		authorizations = append(authorizations, request.Header.Get(auth.AuthorizationHeader))
+		handler.ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: tripperware.DigestAuthentication("foo", "my_password")(http.DefaultTransport)}
+
+	resp, err := client.Post(server.URL+"/my_path?query=1", "text/plain", strings.NewReader("my_body"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "foo my_body", string(body))
+
+	// the challenge is reused for the next request
+	resp, err = client.Get(server.URL + "/my_path")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Len(t, authorizations, 3)
+	assert.Empty(t, authorizations[0])
+	assert.Contains(t, authorizations[1], `algorithm=SHA-256`)
+	assert.Contains(t, authorizations[1], `nc=00000001`)
+	assert.Contains(t, authorizations[1], `uri="/my_path?query=1"`)
+	assert.Contains(t, authorizations[2], `nc=00000002`)
+}
+
+func TestDigestAuthentication_BodyWithoutGetBody(t *testing.T) {
+	server := httptest.NewServer(digestHandler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		_, _ = writer.Write(body)
+	})))
+	defer server.Close()
+
+	request, err := http.NewRequest(http.MethodPost, server.URL, nil)
+	assert.NoError(t, err)
+	request.Body = ioutil.NopCloser(strings.NewReader("my_body"))
+
+	resp, err := tripperware.DigestAuthentication("foo", "my_password")(http.DefaultTransport).RoundTrip(request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "my_body", string(body))
+	// the caller request is not modified
+	assert.Nil(t, request.GetBody)
+}
+
+func TestDigestAuthentication_Hosts(t *testing.T) {
+	var authorizations []string
+	handler := digestHandler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	newServer := func() *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			authorizations = append(authorizations, request.Header.Get(auth.AuthorizationHeader))
+			handler.ServeHTTP(writer, request)
+		}))
+	}
+	server1 := newServer()
+	defer server1.Close()
+	server2 := newServer()
+	defer server2.Close()
+
+	client := &http.Client{Transport: tripperware.DigestAuthentication("foo", "my_password")(http.DefaultTransport)}
+
+	resp, err := client.Get(server1.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	// the challenge of the first host is not sent to the second one
+	resp, err = client.Get(server2.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Len(t, authorizations, 4)
+	assert.Empty(t, authorizations[0])
+	assert.Contains(t, authorizations[1], `nc=00000001`)
+	assert.Empty(t, authorizations[2])
+	assert.Contains(t, authorizations[3], `nc=00000001`)
+}
+
+func TestDigestAuthentication_WrongPassword(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(digestHandler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		calls++
+	})))
+	defer server.Close()
+
+	client := &http.Client{Transport: tripperware.DigestAuthentication("foo", "wrong_password")(http.DefaultTransport)}
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, 0, calls)
+}
+
+func TestDigestAuthentication_BodyNotReplayable(t *testing.T) {
+	calls := 0
+	transport := httpware.RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+		calls++
+		// the server answers before the whole body is sent
+		_, _ = request.Body.Read(make([]byte, 2))
+		_ = request.Body.Close()
+		return &http.Response{
+			StatusCode: http.StatusUnauthorized,
+			Header:     http.Header{"Www-Authenticate": {`Digest realm="my_realm", nonce="my_nonce", qop="auth", algorithm=SHA-256`}},
+			Body:       ioutil.NopCloser(strings.NewReader("my_challenge_body")),
+		}, nil
+	})
+
+	request, err := http.NewRequest(http.MethodPost, "http://fake-addr", nil)
+	assert.NoError(t, err)
+	request.Body = ioutil.NopCloser(strings.NewReader("my_body"))
+
+	resp, err := tripperware.DigestAuthentication("foo", "my_password")(transport).RoundTrip(request)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	// the server response is returned unchanged
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "my_challenge_body", string(body))
+}
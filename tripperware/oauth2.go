@@ -0,0 +1,50 @@
+package tripperware
+
+import (
+	"net/http"
+
+	"github.com/gol4ng/httpware/v4"
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/auth/oauth2"
+)
+
+// OAuth2 tripperware sets the token source access token in the Authorization header
+// when the server answers 401 the token is invalidated and the request is sent once again with a fresh token
+func OAuth2(source oauth2.TokenSource) httpware.Tripperware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return httpware.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			token, err := source.Token(req.Context())
+			if err != nil {
+				return nil, err
+			}
+			req = replayable(req)
+
+			resp, err := next.RoundTrip(withBearerToken(req, token))
+			if err != nil || resp.StatusCode != http.StatusUnauthorized {
+				return resp, err
+			}
+
+			source.Invalidate(token)
+			freshToken, err := source.Token(req.Context())
+			if err != nil || freshToken.AccessToken == token.AccessToken {
+				return resp, nil
+			}
+			retry, ok := retryRequest(req, resp)
+			if !ok {
+				return resp, nil
+			}
+			return next.RoundTrip(withBearerToken(retry, freshToken))
+		})
+	}
+}
+
+// OAuth2ClientCredentials tripperware obtains its tokens with the OAuth2 client credentials grant
+func OAuth2ClientCredentials(tokenURL string, clientID string, clientSecret string, scopes []string, options ...oauth2.Option) httpware.Tripperware {
+	return OAuth2(oauth2.NewClientCredentials(tokenURL, clientID, clientSecret, scopes, options...))
+}
+
+func withBearerToken(req *http.Request, token *oauth2.Token) *http.Request {
+	r := req.Clone(req.Context())
+	r.Header.Set(auth.AuthorizationHeader, auth.FormatCredential(auth.BearerToken(token.AccessToken)))
+	return r
+}
@@ -0,0 +1,74 @@
+package tripperware_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/tripperware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	var tokenCalls int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		n := atomic.AddInt32(&tokenCalls, 1)
+		_, _ = fmt.Fprintf(writer, `{"access_token":"token_%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		authorizations = append(authorizations, request.Header.Get("Authorization"))
+		body, _ := ioutil.ReadAll(request.Body)
+		assert.Equal(t, "my_body", string(body))
+		// the first token is revoked by the server
+		if request.Header.Get("Authorization") == "Bearer token_1" {
+			writer.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: tripperware.OAuth2ClientCredentials(tokenServer.URL, "my_client", "my_secret", []string{"read"})(http.DefaultTransport),
+	}
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("my_body"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = client.Post(server.URL, "text/plain", strings.NewReader("my_body"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, []string{"Bearer token_1", "Bearer token_2", "Bearer token_2"}, authorizations)
+	assert.Equal(t, int32(2), tokenCalls)
+}
+
+func TestOAuth2ClientCredentials_Unauthorized(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(`{"access_token":"my_token","token_type":"Bearer"}`))
+	}))
+	defer tokenServer.Close()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		writer.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: tripperware.OAuth2ClientCredentials(tokenServer.URL, "my_client", "my_secret", nil)(http.DefaultTransport),
+	}
+
+	// the token server gives the same token again, the request is not retried
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, int32(1), calls)
+}
@@ -12,14 +12,15 @@ func RateLimit(rateLimiter rate_limit.RateLimiter, options ...RateLimitOption) h
 
 	return func(next http.RoundTripper) http.RoundTripper {
 		return httpware.RoundTripFunc(func(request *http.Request) (*http.Response, error) {
-			if limitErr := rateLimiter.Allow(request); limitErr != nil {
+			cost := config.Cost(request)
+			if limitErr := rate_limit.AllowN(rateLimiter, request, cost); limitErr != nil {
 				if res, err := config.ErrorCallback(request, limitErr); err != nil {
 					return res, err
 				}
 			}
 
-			rateLimiter.Inc(request)
-			defer rateLimiter.Dec(request)
+			rate_limit.IncN(rateLimiter, request, cost)
+			defer rate_limit.DecN(rateLimiter, request, cost)
 			return next.RoundTrip(request)
 		})
 	}
@@ -32,6 +33,8 @@ type RateLimitOption func(*RateLimitConfig)
 
 type RateLimitConfig struct {
 	ErrorCallback RateLimitErrorCallback
+	// Cost returns the number of tokens consumed by the request
+	Cost rate_limit.CostFunc
 }
 
 func (c *RateLimitConfig) apply(options ...RateLimitOption) *RateLimitConfig {
@@ -44,6 +47,7 @@ func (c *RateLimitConfig) apply(options ...RateLimitOption) *RateLimitConfig {
 func NewRateLimitConfig(options ...RateLimitOption) *RateLimitConfig {
 	config := &RateLimitConfig{
 		ErrorCallback: DefaultRateLimitErrorCallback(),
+		Cost:          rate_limit.DefaultCost,
 	}
 	return config.apply(options...)
 }
@@ -59,3 +63,10 @@ func WithRateLimitErrorCallback(callback RateLimitErrorCallback) RateLimitOption
 		config.ErrorCallback = callback
 	}
 }
+
+// WithRateLimitCost will configure Cost option
+func WithRateLimitCost(cost rate_limit.CostFunc) RateLimitOption {
+	return func(config *RateLimitConfig) {
+		config.Cost = cost
+	}
+}
@@ -60,6 +60,40 @@ func TestRateLimit(t *testing.T) {
 	rateLimiterMock.AssertExpectations(t)
 }
 
+func TestRateLimit_LimitError(t *testing.T) {
+	limiter := rate_limit.NewTokenBucket(time.Minute, 0)
+	defer limiter.Stop()
+
+	client := http.Client{Transport: tripperware.RateLimit(limiter)(&mocks.RoundTripper{})}
+	_, err := client.Get("http://fake-addr")
+
+	assert.True(t, errors.Is(err, rate_limit.ErrLimitReached))
+	limitErr := &rate_limit.LimitError{}
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, time.Minute, limitErr.Window)
+	assert.True(t, limitErr.RetryAfter > 0)
+}
+
+func TestRateLimit_WithRateLimitCost(t *testing.T) {
+	roundTripperMock := &mocks.RoundTripper{}
+	roundTripperMock.On("RoundTrip", mock.AnythingOfType("*http.Request")).Return(&http.Response{StatusCode: http.StatusOK}, nil)
+
+	limiter := rate_limit.NewMultiWindow(rate_limit.Window{Duration: time.Minute, Limit: 10})
+	tr := tripperware.RateLimit(limiter, tripperware.WithRateLimitCost(func(req *http.Request) int {
+		if req.Method == http.MethodPost {
+			return 6
+		}
+		return 1
+	}))(roundTripperMock)
+
+	_, err := tr.RoundTrip(httptest.NewRequest(http.MethodPost, "http://fake-addr", nil))
+	assert.NoError(t, err)
+	_, err = tr.RoundTrip(httptest.NewRequest(http.MethodPost, "http://fake-addr", nil))
+	assert.True(t, errors.Is(err, rate_limit.ErrLimitReached))
+	_, err = tr.RoundTrip(httptest.NewRequest(http.MethodGet, "http://fake-addr", nil))
+	assert.NoError(t, err)
+}
+
 func TestNewConfig(t *testing.T) {
 	res, err := tripperware.NewRateLimitConfig().ErrorCallback(nil, fmt.Errorf("default error"))
 	assert.Nil(t, res)
@@ -123,7 +157,7 @@ func ExampleRateLimit() {
 	// Output:
 	//server receive request
 	//<nil>
-	//request limit reached
+	//request limit reached: 1 per 1s
 	//server receive request
 	//<nil>
 }
@@ -0,0 +1,108 @@
+package tripperware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/gol4ng/httpware/v4/interceptor"
+)
+
+// ErrBodyNotReplayable is returned when a request must be sent again but its body was not entirely sent
+var ErrBodyNotReplayable = errors.New("request body cannot be replayed")
+
+// replayable returns a copy of the request whose body can be read again through GetBody
+// in order to re-send the request after a challenge or an expired credential
+// the given request is not modified, the body is copied while it is sent (see interceptor.NewCopyReadCloser)
+// and only when the request has no GetBody
+func replayable(req *http.Request) *http.Request {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return req
+	}
+	body := &replayBody{ReadCloser: interceptor.NewCopyReadCloser(req.Body)}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	clone.GetBody = body.replay
+	return clone
+}
+
+// replayBody records whether the copy read closer was read until EOF or closed before
+type replayBody struct {
+	io.ReadCloser
+	mutex   sync.Mutex
+	eof     bool
+	closed  bool
+	content []byte
+}
+
+func (b *replayBody) Read(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.eof {
+		return 0, io.EOF
+	}
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+func (b *replayBody) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	if b.eof {
+		// the copy read closer already closed the original body
+		return nil
+	}
+	return b.ReadCloser.Close()
+}
+
+// replay returns the copied body, the remaining part is read when the transport did not send it all
+func (b *replayBody) replay() (io.ReadCloser, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.content == nil {
+		if !b.eof {
+			if b.closed {
+				return nil, ErrBodyNotReplayable
+			}
+			if _, err := io.Copy(ioutil.Discard, b.ReadCloser); err != nil {
+				return nil, err
+			}
+			b.eof = true
+		}
+		// once read until EOF the copy read closer serves the copied body
+		content, err := ioutil.ReadAll(b.ReadCloser)
+		if err != nil {
+			return nil, err
+		}
+		b.content = content
+	}
+	return ioutil.NopCloser(bytes.NewReader(b.content)), nil
+}
+
+// retryRequest returns a copy of the request with a rewound body, ok is false when the body cannot be replayed
+// the response body is drained and closed only when the request can be retried, otherwise the response must be returned
+func retryRequest(req *http.Request, resp *http.Response) (retry *http.Request, ok bool) {
+	retry = req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, false
+		}
+		retry.Body = body
+	}
+	if resp != nil {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	return retry, true
+}
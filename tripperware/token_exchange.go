@@ -0,0 +1,72 @@
+package tripperware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gol4ng/httpware/v4"
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/auth/jwt"
+	"github.com/gol4ng/httpware/v4/auth/oauth2"
+)
+
+// ErrMissingSubjectToken is returned when the request context has no token to exchange
+var ErrMissingSubjectToken = errors.New("token exchange: missing subject token")
+
+// TokenExchange tripperware exchanges the request context credential (see auth.CredentialFromContext)
+// for a token of the downstream audience and sets it in the Authorization header (RFC 8693 delegation)
+// the context credential must be a bearer token, a *jwt.Token or an *oauth2.Introspection,
+// the request fails with ErrMissingSubjectToken otherwise
+// when the server answers 401 the exchanged token is invalidated and the request is sent once again with a fresh token
+func TokenExchange(exchange *oauth2.TokenExchange, audience string, scopes ...string) httpware.Tripperware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return httpware.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			subject, ok := subjectTokenFromCredential(auth.CredentialFromContext(req.Context()))
+			if !ok {
+				return nil, ErrMissingSubjectToken
+			}
+			token, err := exchange.Exchange(req.Context(), subject, audience, scopes)
+			if err != nil {
+				return nil, err
+			}
+			req = replayable(req)
+
+			resp, err := next.RoundTrip(withBearerToken(req, token))
+			if err != nil || resp.StatusCode != http.StatusUnauthorized {
+				return resp, err
+			}
+
+			exchange.Invalidate(subject, audience, scopes, token)
+			freshToken, err := exchange.Exchange(req.Context(), subject, audience, scopes)
+			if err != nil || freshToken.AccessToken == token.AccessToken {
+				return resp, nil
+			}
+			retry, ok := retryRequest(req, resp)
+			if !ok {
+				return resp, nil
+			}
+			return next.RoundTrip(withBearerToken(retry, freshToken))
+		})
+	}
+}
+
+// subjectTokenFromCredential returns the token to exchange with its RFC 8693 type and its expiry
+func subjectTokenFromCredential(credential auth.Credential) (oauth2.SubjectToken, bool) {
+	if raw, ok := credential.(string); ok {
+		parsed, err := auth.ParseCredential(raw)
+		if err != nil {
+			return oauth2.SubjectToken{}, false
+		}
+		credential = parsed
+	}
+	var subject oauth2.SubjectToken
+	switch c := credential.(type) {
+	case auth.BearerToken:
+		subject = oauth2.SubjectToken{Token: string(c), Type: oauth2.AccessTokenType}
+	case *jwt.Token:
+		subject = oauth2.SubjectToken{Token: c.Raw, Type: oauth2.JWTTokenType, Expiry: c.ExpiresAt()}
+	case *oauth2.Introspection:
+		subject = oauth2.SubjectToken{Token: c.Token, Type: oauth2.AccessTokenType, Expiry: c.ExpiresAt()}
+	}
+	return subject, subject.Token != ""
+}
@@ -0,0 +1,65 @@
+package tripperware_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gol4ng/httpware/v4/auth"
+	"github.com/gol4ng/httpware/v4/auth/oauth2"
+	"github.com/gol4ng/httpware/v4/tripperware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenExchange(t *testing.T) {
+	var stsCalls int32
+	sts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		n := atomic.AddInt32(&stsCalls, 1)
+		assert.Equal(t, oauth2.TokenExchangeGrantType, request.PostFormValue("grant_type"))
+		assert.Equal(t, oauth2.AccessTokenType, request.PostFormValue("subject_token_type"))
+		assert.Equal(t, "my_api", request.PostFormValue("audience"))
+		_, _ = fmt.Fprintf(writer, `{"access_token":"%s_%d","token_type":"Bearer","expires_in":3600}`, request.PostFormValue("subject_token"), n)
+	}))
+	defer sts.Close()
+
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		authorizations = append(authorizations, request.Header.Get(auth.AuthorizationHeader))
+		// the first exchanged token is revoked by the server
+		if request.Header.Get(auth.AuthorizationHeader) == "Bearer user_token_1" {
+			writer.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: tripperware.TokenExchange(oauth2.NewTokenExchange(sts.URL, "my_client", "my_secret"), "my_api")(http.DefaultTransport),
+	}
+	send := func(credential auth.Credential) (*http.Response, error) {
+		request, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("my_body"))
+		if credential != nil {
+			request = request.WithContext(auth.CredentialToContext(context.Background(), credential))
+		}
+		return client.Do(request)
+	}
+
+	resp, err := send(auth.BearerToken("user_token"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = send("Bearer user_token")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	// the introspected token is exchanged as well
+	resp, err = send(&oauth2.Introspection{Active: true, Token: "user_token"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"Bearer user_token_1", "Bearer user_token_2", "Bearer user_token_2", "Bearer user_token_2"}, authorizations)
+	assert.Equal(t, int32(2), stsCalls)
+
+	_, err = send(nil)
+	assert.Contains(t, err.Error(), tripperware.ErrMissingSubjectToken.Error())
+}